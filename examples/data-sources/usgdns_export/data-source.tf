@@ -0,0 +1,7 @@
+# Export all records as JSON.
+data "usgdns_export" "export" {}
+
+resource "local_file" "backup" {
+  filename = "records.json"
+  content  = data.usgdns_export.export.json
+}
@@ -0,0 +1,9 @@
+# Preview the changes needed to reach the desired records.
+data "usgdns_records_preview" "preview" {
+  records = [
+    {
+      name   = "example.com"
+      target = "127.0.0.1"
+    },
+  ]
+}
@@ -0,0 +1,14 @@
+# Compute the changes needed to reach the desired records.
+data "usgdns_records" "records" {}
+
+output "changes" {
+  value = provider::usgdns::diff_records(
+    [
+      {
+        name   = "example.com"
+        target = "127.0.0.1"
+      },
+    ],
+    data.usgdns_records.records.records,
+  )
+}
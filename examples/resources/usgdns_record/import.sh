@@ -1,2 +1,6 @@
 # Record can be imported by specifying the UUID identifier.
 terraform import usgdns_record.example 4192a280-58ab-44a0-a999-3dcb463c989e
+
+# Record configured with sensitive_target can be imported by prefixing the
+# identifier with "sensitive:", so the target is not shown by the next plan.
+terraform import usgdns_record.example sensitive:4192a280-58ab-44a0-a999-3dcb463c989e
@@ -0,0 +1,35 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+
+	"terraform-provider-usgdns/internal/usgdns"
+)
+
+// addClientError adds the diagnostic for an error returned by the usg-dns
+// client, replacing the generic summary with a dedicated guidance when the
+// cause of the error is known.
+func addClientError(diags *diag.Diagnostics, err error, summary, detail string) {
+	switch {
+	case errors.Is(err, usgdns.ErrTokenNotYetValid):
+		diags.AddError(
+			"usg-dns API token not yet valid",
+			"The server refused the token as not yet valid. This usually means the local clock is ahead of the "+
+				"server clock: check the time synchronization (e.g. NTP) of the host running Terraform.\n\n"+detail,
+		)
+	case errors.Is(err, usgdns.ErrHTMLResponse):
+		diags.AddError(
+			"usg-dns API URL points at a web page",
+			"The server answered with an HTML page instead of JSON. The URL likely points at a web UI rather than "+
+				"at the usg-dns-api server: set it to the root of the usg-dns-api server, the provider adds the "+
+				"/records path itself.\n\n"+detail,
+		)
+	default:
+		diags.AddError(summary, detail)
+	}
+}
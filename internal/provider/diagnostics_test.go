@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+
+	"terraform-provider-usgdns/internal/usgdns"
+)
+
+// readRecordsDiagnostics reads the records data source from a server
+// answering with the given handler, and returns the diagnostics.
+func readRecordsDiagnostics(t *testing.T, handler http.HandlerFunc) diag.Diagnostics {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := usgdns.NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &recordsDataSource{client: client}
+	var schemaResp datasource.SchemaResponse
+	d.Schema(context.Background(), datasource.SchemaRequest{}, &schemaResp)
+
+	config := tfsdk.Config{Schema: schemaResp.Schema, Raw: objectValue(t, schemaResp.Schema, nil)}
+	resp := datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(context.Background(), datasource.ReadRequest{Config: config}, &resp)
+
+	return resp.Diagnostics
+}
+
+// assertErrorSummary checks the diagnostics hold a single error with the
+// given summary.
+func assertErrorSummary(t *testing.T, diags diag.Diagnostics, summary string) {
+	t.Helper()
+
+	errors := diags.Errors()
+	if len(errors) != 1 || errors[0].Summary() != summary {
+		t.Errorf("expected the error %q, got %v", summary, diags)
+	}
+}
+
+func TestClientErrorTokenNotYetValid(t *testing.T) {
+	diags := readRecordsDiagnostics(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"Token not yet valid"}`))
+	})
+
+	assertErrorSummary(t, diags, "usg-dns API token not yet valid")
+}
+
+func TestClientErrorGeneric(t *testing.T) {
+	diags := readRecordsDiagnostics(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"invalid token"}`))
+	})
+
+	assertErrorSummary(t, diags, "Unable to fetch the usg-dns records")
+}
+
+func TestClientErrorHTMLResponse(t *testing.T) {
+	for _, statusCode := range []int{http.StatusOK, http.StatusNotFound} {
+		diags := readRecordsDiagnostics(t, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(statusCode)
+			_, _ = w.Write([]byte("<html><body>UniFi</body></html>"))
+		})
+
+		assertErrorSummary(t, diags, "usg-dns API URL points at a web page")
+	}
+}
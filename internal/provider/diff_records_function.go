@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	usgdnsdb "github.com/rclsilver-org/usg-dns-api/db"
+
+	"terraform-provider-usgdns/internal/usgdns"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ function.Function = &diffRecordsFunction{}
+)
+
+// recordSpecObjectType is the object type of the records handled by the functions.
+var recordSpecObjectType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"name":   types.StringType,
+		"target": types.StringType,
+	},
+}
+
+// diffRecordsFunctionResultModel maps the function result.
+type diffRecordsFunctionResultModel struct {
+	Create []recordSpecModel `tfsdk:"create"`
+	Update []recordSpecModel `tfsdk:"update"`
+	Delete []recordSpecModel `tfsdk:"delete"`
+}
+
+func NewDiffRecordsFunction() function.Function {
+	return &diffRecordsFunction{}
+}
+
+type diffRecordsFunction struct{}
+
+func (f *diffRecordsFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "diff_records"
+}
+
+func (f *diffRecordsFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Compute the changes between two sets of records.",
+		Description: "Compare the desired records with the actual ones and return the records to create, to update " +
+			"and to delete. Records are matched by their normalized name, which must be unique in each list.",
+		Parameters: []function.Parameter{
+			function.ListParameter{
+				Name:        "desired",
+				Description: "Desired records.",
+				ElementType: recordSpecObjectType,
+			},
+			function.ListParameter{
+				Name:        "actual",
+				Description: "Actual records, e.g. the records of the usgdns_records data source.",
+				ElementType: recordSpecObjectType,
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: map[string]attr.Type{
+				"create": types.ListType{ElemType: recordSpecObjectType},
+				"update": types.ListType{ElemType: recordSpecObjectType},
+				"delete": types.ListType{ElemType: recordSpecObjectType},
+			},
+		},
+	}
+}
+
+func (f *diffRecordsFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var desired, actual []recordSpecModel
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &desired, &actual))
+	if resp.Error != nil {
+		return
+	}
+
+	desiredRecords, err := normalizedRecords(desired)
+	if err != nil {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewArgumentFuncError(0, err.Error()))
+	}
+	actualRecords, err := normalizedRecords(actual)
+	if err != nil {
+		resp.Error = function.ConcatFuncErrors(resp.Error, function.NewArgumentFuncError(1, err.Error()))
+	}
+	if resp.Error != nil {
+		return
+	}
+
+	changes := usgdns.DiffRecords(desiredRecords, actualRecords)
+
+	result := diffRecordsFunctionResultModel{
+		Create: recordSpecModels(changes.Create),
+		Update: recordSpecModels(changes.Update),
+		Delete: recordSpecModels(changes.Delete),
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, result))
+}
+
+// normalizedRecords maps models to API records with normalized names. The
+// records are matched by name, so the normalized names must be unique.
+func normalizedRecords(models []recordSpecModel) ([]usgdnsdb.Record, error) {
+	records := make([]usgdnsdb.Record, 0, len(models))
+	seen := make(map[string]bool, len(models))
+	for _, model := range models {
+		name, err := usgdns.NormalizeName(model.Name.ValueString())
+		if err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate name %q", name)
+		}
+		seen[name] = true
+		records = append(records, usgdnsdb.Record{
+			Name:   name,
+			Target: model.Target.ValueString(),
+		})
+	}
+	return records, nil
+}
+
+// recordSpecModels maps API records to models.
+func recordSpecModels(records []usgdnsdb.Record) []recordSpecModel {
+	models := []recordSpecModel{}
+	for _, record := range records {
+		models = append(models, recordSpecModel{
+			Name:   types.StringValue(record.Name),
+			Target: types.StringValue(record.Target),
+		})
+	}
+	return models
+}
@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// recordSpecList builds a list of records from name and target pairs.
+func recordSpecList(t *testing.T, pairs ...string) types.List {
+	t.Helper()
+
+	elements := make([]attr.Value, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		elements = append(elements, types.ObjectValueMust(recordSpecObjectType.AttrTypes, map[string]attr.Value{
+			"name":   types.StringValue(pairs[i]),
+			"target": types.StringValue(pairs[i+1]),
+		}))
+	}
+
+	return types.ListValueMust(recordSpecObjectType, elements)
+}
+
+// runDiffRecords runs the diff_records function.
+func runDiffRecords(t *testing.T, desired, actual types.List) (diffRecordsFunctionResultModel, *function.FuncError) {
+	t.Helper()
+
+	ctx := context.Background()
+	f := NewDiffRecordsFunction()
+
+	var definition function.DefinitionResponse
+	f.Definition(ctx, function.DefinitionRequest{}, &definition)
+	returnType, ok := definition.Definition.Return.GetType().(types.ObjectType)
+	if !ok {
+		t.Fatalf("unexpected return type %T", definition.Definition.Return.GetType())
+	}
+
+	req := function.RunRequest{Arguments: function.NewArgumentsData([]attr.Value{desired, actual})}
+	resp := function.RunResponse{Result: function.NewResultData(types.ObjectUnknown(returnType.AttrTypes))}
+	f.Run(ctx, req, &resp)
+
+	var result diffRecordsFunctionResultModel
+	if resp.Error == nil {
+		object, ok := resp.Result.Value().(types.Object)
+		if !ok {
+			t.Fatalf("unexpected result type %T", resp.Result.Value())
+		}
+		if diags := object.As(ctx, &result, basetypes.ObjectAsOptions{}); diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+	}
+
+	return result, resp.Error
+}
+
+// specNames returns the names of the records.
+func specNames(models []recordSpecModel) []string {
+	names := make([]string, 0, len(models))
+	for _, model := range models {
+		names = append(names, model.Name.ValueString())
+	}
+	return names
+}
+
+func TestDiffRecordsFunction(t *testing.T) {
+	result, err := runDiffRecords(t,
+		recordSpecList(t,
+			"kept.lan", "10.0.0.1",
+			"changed.lan.", "10.0.0.2",
+			"added.lan", "10.0.0.3",
+		),
+		recordSpecList(t,
+			"kept.lan", "10.0.0.1",
+			"changed.lan", "10.0.0.20",
+			"removed.lan", "10.0.0.4",
+		),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := specNames(result.Create); !slices.Equal(got, []string{"added.lan"}) {
+		t.Errorf("unexpected records to create: %v", got)
+	}
+	if got := specNames(result.Delete); !slices.Equal(got, []string{"removed.lan"}) {
+		t.Errorf("unexpected records to delete: %v", got)
+	}
+	if got := specNames(result.Update); !slices.Equal(got, []string{"changed.lan"}) {
+		t.Errorf("unexpected records to update: %v", got)
+	} else if target := result.Update[0].Target.ValueString(); target != "10.0.0.2" {
+		t.Errorf("the update should carry the desired target, got %q", target)
+	}
+}
+
+func TestDiffRecordsFunctionDuplicates(t *testing.T) {
+	tests := map[string]struct {
+		desired  types.List
+		actual   types.List
+		argument int64
+	}{
+		"desired": {
+			desired:  recordSpecList(t, "dup.lan", "10.0.0.1", "dup.lan.", "10.0.0.2"),
+			actual:   recordSpecList(t),
+			argument: 0,
+		},
+		"actual": {
+			desired:  recordSpecList(t),
+			actual:   recordSpecList(t, "dup.lan", "10.0.0.1", "dup.lan", "10.0.0.2"),
+			argument: 1,
+		},
+	}
+
+	for name, test := range tests {
+		_, err := runDiffRecords(t, test.desired, test.actual)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+			continue
+		}
+		if err.FunctionArgument == nil || *err.FunctionArgument != test.argument {
+			t.Errorf("%s: expected an error on argument %d, got %v", name, test.argument, err.FunctionArgument)
+		}
+		if !strings.Contains(err.Text, `duplicate name "dup.lan"`) {
+			t.Errorf("%s: unexpected error %q", name, err.Text)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"terraform-provider-usgdns/internal/usgdns"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &exportDataSource{}
+	_ datasource.DataSourceWithConfigure = &exportDataSource{}
+)
+
+// exportDataSourceModel maps the data source schema data.
+type exportDataSourceModel struct {
+	NamePrefix types.String `tfsdk:"name_prefix"`
+	JSON       types.String `tfsdk:"json"`
+}
+
+// exportedRecord is the exported form of a record. Its fields are sorted so
+// the keys of the exported JSON are in a stable, alphabetical order.
+type exportedRecord struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Target string `json:"target"`
+}
+
+func NewExportDataSource() datasource.DataSource {
+	return &exportDataSource{}
+}
+
+type exportDataSource struct {
+	client *usgdns.Client
+}
+
+func (d *exportDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_export"
+}
+
+func (d *exportDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Export the records as JSON, sorted by name, for backup or audit purposes.",
+		Attributes: map[string]schema.Attribute{
+			"name_prefix": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only export the records whose name starts with this prefix, compared case-insensitively.",
+			},
+			"json": schema.StringAttribute{
+				Computed:    true,
+				Description: "JSON array of the exported records.",
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *exportDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*usgdns.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *usgdns.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *exportDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state exportDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	records, err := d.client.GetRecords(ctx)
+	if err != nil {
+		addClientError(&resp.Diagnostics, err,
+			"Unable to fetch the usg-dns records",
+			err.Error(),
+		)
+		return
+	}
+
+	// The prefix is matched case-insensitively, like the managed prefix
+	namePrefix := strings.ToLower(state.NamePrefix.ValueString())
+
+	exported := []exportedRecord{}
+	for _, record := range records {
+		if !strings.HasPrefix(strings.ToLower(record.Name), namePrefix) {
+			continue
+		}
+		exported = append(exported, exportedRecord{
+			ID:     record.ID,
+			Name:   record.Name,
+			Target: record.Target,
+		})
+	}
+
+	// Sort the records so the export only changes with the records
+	slices.SortFunc(exported, func(a, b exportedRecord) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+
+	exportedJSON, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to export the usg-dns records",
+			err.Error(),
+		)
+		return
+	}
+	state.JSON = types.StringValue(string(exportedJSON))
+
+	// Set state
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"terraform-provider-usgdns/internal/usgdns"
+)
+
+func TestExportDataSourceRead(t *testing.T) {
+	server := newJSONServer(t, http.StatusOK, []map[string]string{
+		{"id": "2", "name": "app-a.lan", "target": "10.0.0.2"},
+		{"id": "4", "name": "other.lan", "target": "10.0.0.4"},
+		{"id": "3", "name": "APP-b.lan", "target": "10.0.0.3"},
+		{"id": "1", "name": "app-a.lan", "target": "10.0.0.1"},
+	}, nil)
+	client, err := usgdns.NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &exportDataSource{client: client}
+	var schemaResp datasource.SchemaResponse
+	d.Schema(context.Background(), datasource.SchemaRequest{}, &schemaResp)
+
+	config := tfsdk.Config{
+		Schema: schemaResp.Schema,
+		Raw: objectValue(t, schemaResp.Schema, map[string]tftypes.Value{
+			"name_prefix": stringValue("App-"),
+		}),
+	}
+	resp := datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+
+	d.Read(context.Background(), datasource.ReadRequest{Config: config}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	var state exportDataSourceModel
+	resp.State.Get(context.Background(), &state)
+
+	want, err := os.ReadFile("testdata/export.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The golden file ends with a newline, unlike the export
+	if got := state.JSON.ValueString() + "\n"; got != string(want) {
+		t.Errorf("unexpected export:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"terraform-provider-usgdns/internal/usgdns"
+)
+
+// schemaWithType is implemented by the provider, resource and data source schemas.
+type schemaWithType interface {
+	Type() attr.Type
+}
+
+// objectValue builds a value of the schema type, the attributes missing from
+// values being null.
+func objectValue(t *testing.T, s schemaWithType, values map[string]tftypes.Value) tftypes.Value {
+	t.Helper()
+
+	objectType, ok := s.Type().TerraformType(context.Background()).(tftypes.Object)
+	if !ok {
+		t.Fatalf("unexpected schema type %T", s.Type())
+	}
+
+	attributes := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attributeType := range objectType.AttributeTypes {
+		if value, ok := values[name]; ok {
+			attributes[name] = value
+		} else {
+			attributes[name] = tftypes.NewValue(attributeType, nil)
+		}
+	}
+
+	return tftypes.NewValue(objectType, attributes)
+}
+
+// stringValue builds a tftypes string value.
+func stringValue(value string) tftypes.Value {
+	return tftypes.NewValue(tftypes.String, value)
+}
+
+// newRecordResource returns a record resource and its schema, configured
+// with a client talking to the given server URL.
+func newRecordResource(t *testing.T, url string) (*recordResource, resource.SchemaResponse) {
+	t.Helper()
+
+	r := &recordResource{}
+	if url != "" {
+		client, err := usgdns.NewClient(url, "token")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.client = client
+	}
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(context.Background(), resource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", schemaResp.Diagnostics)
+	}
+
+	return r, schemaResp
+}
+
+// newJSONServer starts a server answering every request with the given status
+// code and JSON body, and sending the received requests to the channel when
+// not nil.
+func newJSONServer(t *testing.T, statusCode int, body any, requests chan<- *http.Request) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests != nil {
+			requests <- r
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
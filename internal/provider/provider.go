@@ -5,9 +5,13 @@ package provider
 
 import (
 	"context"
+	"net"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
@@ -19,7 +23,8 @@ import (
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ provider.Provider = &usgDnsProvider{}
+	_ provider.Provider              = &usgDnsProvider{}
+	_ provider.ProviderWithFunctions = &usgDnsProvider{}
 )
 
 const (
@@ -27,9 +32,25 @@ const (
 	envCfgToken = "USG_DNS_TOKEN"
 )
 
+// Environment variables describing the Terraform run, forwarded to the
+// server for auditing purposes when present.
+const (
+	envTfWorkspace    = "TF_WORKSPACE"
+	envTfcWorkspace   = "TFC_WORKSPACE_NAME"
+	envTfcRunID       = "TFC_RUN_ID"
+	headerTfWorkspace = "X-Terraform-Workspace"
+	headerTfRunID     = "X-Terraform-Run-Id"
+)
+
 type usgDnsProviderModel struct {
-	URL   types.String `tfsdk:"url"`
-	Token types.String `tfsdk:"token"`
+	URL                 types.String `tfsdk:"url"`
+	Token               types.String `tfsdk:"token"`
+	LocalAddress        types.String `tfsdk:"local_address"`
+	Locale              types.String `tfsdk:"locale"`
+	ManagedPrefix       types.String `tfsdk:"managed_prefix"`
+	IDField             types.String `tfsdk:"id_field"`
+	ForbidSelfReference types.Bool   `tfsdk:"forbid_self_reference"`
+	MaxRequestBytes     types.Int64  `tfsdk:"max_request_bytes"`
 }
 
 // New is a helper function to simplify provider server and testing implementation.
@@ -69,6 +90,31 @@ func (p *usgDnsProvider) Schema(_ context.Context, _ provider.SchemaRequest, res
 				Sensitive:   true,
 				Description: "The usg-dns-api server token. May also be provided via " + envCfgToken + " environment variable.",
 			},
+			"local_address": schema.StringAttribute{
+				Optional:    true,
+				Description: "The local IP address the connections to the usg-dns-api server are bound to.",
+			},
+			"locale": schema.StringAttribute{
+				Optional:    true,
+				Description: "The locale sent in the Accept-Language header, e.g. `en` to get predictable error messages.",
+			},
+			"forbid_self_reference": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Refuse to create or update the records whose target is an address of the usg-dns-api server itself.",
+			},
+			"id_field": schema.StringAttribute{
+				Optional:    true,
+				Description: "The response field holding the record ID, one of `" + strings.Join(usgdns.IDFields, "`, `") + "`. Defaults to `id`.",
+			},
+			"max_request_bytes": schema.Int64Attribute{
+				Optional:    true,
+				Description: "The maximum size in bytes of a request body. Larger requests are refused before being sent.",
+			},
+			"managed_prefix": schema.StringAttribute{
+				Optional: true,
+				Description: "Only the records whose name starts with this prefix are seen by the data sources, " +
+					"which keeps this configuration away from the records owned by others in a shared server.",
+			},
 		},
 	}
 }
@@ -104,6 +150,54 @@ func (p *usgDnsProvider) Configure(ctx context.Context, req provider.ConfigureRe
 		)
 	}
 
+	// The data sources would see the records owned by others in a shared
+	// server if the managed prefix was ignored
+	if config.ManagedPrefix.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("managed_prefix"),
+			"Unknown usg-dns managed prefix",
+			"The provider cannot create the usg-dns API client as there is an unknown configuration value for the managed prefix. "+
+				"Either target apply the source of the value first, or set the value statically in the configuration.",
+		)
+	}
+
+	// An unknown value would silently disable the check
+	if config.ForbidSelfReference.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("forbid_self_reference"),
+			"Unknown usg-dns self reference check",
+			"The provider cannot create the usg-dns API client as there is an unknown configuration value for the self reference check. "+
+				"Either target apply the source of the value first, or set the value statically in the configuration.",
+		)
+	}
+
+	if config.LocalAddress.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("local_address"),
+			"Unknown usg-dns API local address",
+			"The provider cannot create the usg-dns API client as there is an unknown configuration value for the local address. "+
+				"Either target apply the source of the value first, or set the value statically in the configuration.",
+		)
+	}
+
+	if config.IDField.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("id_field"),
+			"Unknown usg-dns API ID field",
+			"The provider cannot create the usg-dns API client as there is an unknown configuration value for the ID field. "+
+				"Either target apply the source of the value first, or set the value statically in the configuration.",
+		)
+	}
+
+	if config.MaxRequestBytes.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("max_request_bytes"),
+			"Unknown usg-dns API maximum request size",
+			"The provider cannot create the usg-dns API client as there is an unknown configuration value for the maximum request size. "+
+				"Either target apply the source of the value first, or set the value statically in the configuration.",
+		)
+	}
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -145,12 +239,88 @@ func (p *usgDnsProvider) Configure(ctx context.Context, req provider.ConfigureRe
 		)
 	}
 
+	// The URL sometimes includes the records endpoint, which would make
+	// every request hit a non existing path.
+	if trimmedURL, ok := usgdns.TrimRecordsPath(url); ok {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("url"),
+			"usg-dns API URL includes the records path",
+			"The configured URL "+url+" ends with the records endpoint path, which is added by the provider. "+
+				"The provider will use "+trimmedURL+" instead. Set the URL to the server root to remove this warning.",
+		)
+		url = trimmedURL
+	}
+
+	var opts []usgdns.Option
+
+	if !config.LocalAddress.IsNull() {
+		localAddress := net.ParseIP(config.LocalAddress.ValueString())
+		if localAddress == nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("local_address"),
+				"Invalid local address",
+				"The provider cannot create the usg-dns API client as the local address is not a valid IP address: "+config.LocalAddress.ValueString(),
+			)
+		} else {
+			opts = append(opts, usgdns.WithLocalAddress(localAddress))
+		}
+	}
+
+	if !config.Locale.IsNull() && config.Locale.ValueString() != "" {
+		opts = append(opts, usgdns.WithHeader("Accept-Language", config.Locale.ValueString()))
+	}
+
+	if !config.IDField.IsNull() {
+		if !slices.Contains(usgdns.IDFields, config.IDField.ValueString()) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("id_field"),
+				"Invalid ID field",
+				"The provider cannot create the usg-dns API client as the ID field must be one of "+
+					strings.Join(usgdns.IDFields, ", ")+", got: "+config.IDField.ValueString(),
+			)
+		} else {
+			opts = append(opts, usgdns.WithIDField(config.IDField.ValueString()))
+		}
+	}
+
+	if config.ForbidSelfReference.ValueBool() {
+		opts = append(opts, usgdns.WithForbidSelfReference())
+	}
+
+	if !config.MaxRequestBytes.IsNull() {
+		if config.MaxRequestBytes.ValueInt64() <= 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_request_bytes"),
+				"Invalid maximum request size",
+				"The provider cannot create the usg-dns API client as the maximum request size must be positive.",
+			)
+		} else {
+			opts = append(opts, usgdns.WithMaxRequestBytes(config.MaxRequestBytes.ValueInt64()))
+		}
+	}
+
+	if !config.ManagedPrefix.IsNull() && config.ManagedPrefix.ValueString() != "" {
+		opts = append(opts, usgdns.WithManagedPrefix(config.ManagedPrefix.ValueString()))
+	}
+
+	// Tag the requests with the Terraform run context, if any
+	workspace := os.Getenv(envTfWorkspace)
+	if workspace == "" {
+		workspace = os.Getenv(envTfcWorkspace)
+	}
+	if workspace != "" {
+		opts = append(opts, usgdns.WithHeader(headerTfWorkspace, workspace))
+	}
+	if runID := os.Getenv(envTfcRunID); runID != "" {
+		opts = append(opts, usgdns.WithHeader(headerTfRunID, runID))
+	}
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	// Create a new usg-dns client using the configuration values
-	client, err := usgdns.NewClient(url, token)
+	client, err := usgdns.NewClient(url, token, opts...)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to Create usg-dns API Client",
@@ -171,6 +341,8 @@ func (p *usgDnsProvider) Configure(ctx context.Context, req provider.ConfigureRe
 func (p *usgDnsProvider) DataSources(_ context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewRecordsDataSource,
+		NewRecordsPreviewDataSource,
+		NewExportDataSource,
 	}
 }
 
@@ -180,3 +352,10 @@ func (p *usgDnsProvider) Resources(_ context.Context) []func() resource.Resource
 		NewRecordResource,
 	}
 }
+
+// Functions defines the functions implemented in the provider.
+func (p *usgDnsProvider) Functions(_ context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewDiffRecordsFunction,
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"terraform-provider-usgdns/internal/usgdns"
+)
+
+// configureProvider configures the provider with the given attributes, the
+// token being set when missing.
+func configureProvider(t *testing.T, values map[string]tftypes.Value) (*usgdns.Client, provider.ConfigureResponse) {
+	t.Helper()
+
+	resp := configureProviderResponse(t, values)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	client, ok := resp.ResourceData.(*usgdns.Client)
+	if !ok {
+		t.Fatalf("unexpected resource data %T", resp.ResourceData)
+	}
+	return client, resp
+}
+
+// configureProviderResponse configures the provider with the given
+// attributes, the token being set when missing, and returns the response.
+func configureProviderResponse(t *testing.T, values map[string]tftypes.Value) provider.ConfigureResponse {
+	t.Helper()
+
+	p := New("test")()
+	var schemaResp provider.SchemaResponse
+	p.Schema(context.Background(), provider.SchemaRequest{}, &schemaResp)
+
+	if _, ok := values["token"]; !ok {
+		values["token"] = stringValue("token")
+	}
+	config := tfsdk.Config{Schema: schemaResp.Schema, Raw: objectValue(t, schemaResp.Schema, values)}
+
+	var resp provider.ConfigureResponse
+	p.Configure(context.Background(), provider.ConfigureRequest{Config: config}, &resp)
+
+	return resp
+}
+
+// receivedHeaders configures the provider with the given attributes and
+// returns the headers received by the server on a request.
+func receivedHeaders(t *testing.T, values map[string]tftypes.Value) http.Header {
+	t.Helper()
+
+	requests := make(chan *http.Request, 1)
+	server := newJSONServer(t, http.StatusOK, []any{}, requests)
+	values["url"] = stringValue(server.URL)
+
+	client, _ := configureProvider(t, values)
+	if _, err := client.GetRecords(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return (<-requests).Header
+}
+
+func TestProviderLocale(t *testing.T) {
+	header := receivedHeaders(t, map[string]tftypes.Value{
+		"locale": stringValue("fr-FR"),
+	})
+	if got := header.Get("Accept-Language"); got != "fr-FR" {
+		t.Errorf("expected the Accept-Language header fr-FR, got %q", got)
+	}
+
+	header = receivedHeaders(t, map[string]tftypes.Value{})
+	if got := header.Get("Accept-Language"); got != "" {
+		t.Errorf("expected no Accept-Language header without locale, got %q", got)
+	}
+}
+
+func TestProviderURLWithRecordsPath(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	server := newJSONServer(t, http.StatusOK, []any{}, requests)
+
+	client, resp := configureProvider(t, map[string]tftypes.Value{
+		"url": stringValue(server.URL + "/records/"),
+	})
+
+	warnings := resp.Diagnostics.Warnings()
+	if len(warnings) != 1 || warnings[0].Summary() != "usg-dns API URL includes the records path" {
+		t.Errorf("expected the records path warning, got %v", warnings)
+	}
+
+	if _, err := client.GetRecords(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := (<-requests).URL.Path; got != "/records" {
+		t.Errorf("expected the request to hit /records, got %s", got)
+	}
+}
+
+func TestProviderRunContextHeaders(t *testing.T) {
+	t.Setenv(envTfWorkspace, "staging")
+	t.Setenv(envTfcWorkspace, "")
+	t.Setenv(envTfcRunID, "run-123")
+
+	header := receivedHeaders(t, map[string]tftypes.Value{})
+	if got := header.Get(headerTfWorkspace); got != "staging" {
+		t.Errorf("expected the %s header staging, got %q", headerTfWorkspace, got)
+	}
+	if got := header.Get(headerTfRunID); got != "run-123" {
+		t.Errorf("expected the %s header run-123, got %q", headerTfRunID, got)
+	}
+
+	// The HCP Terraform workspace is used when TF_WORKSPACE is not set
+	t.Setenv(envTfWorkspace, "")
+	t.Setenv(envTfcWorkspace, "hcp-workspace")
+	t.Setenv(envTfcRunID, "")
+
+	header = receivedHeaders(t, map[string]tftypes.Value{})
+	if got := header.Get(headerTfWorkspace); got != "hcp-workspace" {
+		t.Errorf("expected the %s header hcp-workspace, got %q", headerTfWorkspace, got)
+	}
+	if _, ok := header[headerTfRunID]; ok {
+		t.Errorf("expected no %s header without run ID", headerTfRunID)
+	}
+}
+
+func TestProviderUnknownValues(t *testing.T) {
+	tests := map[string]tftypes.Type{
+		"max_request_bytes":     tftypes.Number,
+		"id_field":              tftypes.String,
+		"local_address":         tftypes.String,
+		"managed_prefix":        tftypes.String,
+		"forbid_self_reference": tftypes.Bool,
+	}
+
+	for attribute, attributeType := range tests {
+		resp := configureProviderResponse(t, map[string]tftypes.Value{
+			"url":     stringValue("http://127.0.0.1"),
+			attribute: tftypes.NewValue(attributeType, tftypes.UnknownValue),
+		})
+
+		errors := resp.Diagnostics.Errors()
+		if len(errors) != 1 {
+			t.Errorf("%s: expected a single error, got %v", attribute, resp.Diagnostics)
+			continue
+		}
+		withPath, ok := errors[0].(diag.DiagnosticWithPath)
+		if !ok || !withPath.Path().Equal(path.Root(attribute)) || !strings.HasPrefix(errors[0].Summary(), "Unknown ") {
+			t.Errorf("%s: expected an unknown value error on the attribute, got %v", attribute, errors[0])
+		}
+		if resp.ResourceData != nil || resp.DataSourceData != nil {
+			t.Errorf("%s: no client should be configured", attribute)
+		}
+	}
+}
@@ -6,21 +6,28 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/netip"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/hashicorp/terraform-plugin-log/tflog"
 
 	"terraform-provider-usgdns/internal/usgdns"
 )
 
+// sensitiveImportPrefix prefixes the import ID of the records whose target
+// is configured with sensitive_target.
+const sensitiveImportPrefix = "sensitive:"
+
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource                = &recordResource{}
-	_ resource.ResourceWithConfigure   = &recordResource{}
-	_ resource.ResourceWithImportState = &recordResource{}
+	_ resource.Resource                   = &recordResource{}
+	_ resource.ResourceWithConfigure      = &recordResource{}
+	_ resource.ResourceWithImportState    = &recordResource{}
+	_ resource.ResourceWithModifyPlan     = &recordResource{}
+	_ resource.ResourceWithValidateConfig = &recordResource{}
 )
 
 // NewRecordResource is a helper function to simplify the provider implementation.
@@ -51,9 +58,18 @@ func (r *recordResource) Schema(_ context.Context, _ resource.SchemaRequest, res
 				Required:    true,
 				Description: "Name of the record.",
 			},
+			"normalized_name": schema.StringAttribute{
+				Computed:    true,
+				Description: "Name of the record as sent to the server: without the trailing dot and, for internationalized names, IDNA encoded.",
+			},
 			"target": schema.StringAttribute{
-				Required:    true,
-				Description: "Target of the record.",
+				Optional:    true,
+				Description: "Target of the record. Exactly one of `target` or `sensitive_target` must be set.",
+			},
+			"sensitive_target": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Target of the record, masked in the plan output. The masking is plan-only: the value is stored as is in the state, and the data sources (`usgdns_records`, `usgdns_records_preview` and `usgdns_export`) do not mask the targets they return. Prefix the import ID with `sensitive:` to import the target into this attribute. Exactly one of `target` or `sensitive_target` must be set.",
 			},
 		},
 	}
@@ -82,10 +98,112 @@ func (r *recordResource) Configure(_ context.Context, req resource.ConfigureRequ
 
 // ImportState imports the resource and sets the Terraform state.
 func (r *recordResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	// The target is read into sensitive_target when the ID has the prefix,
+	// so the next plan does not show it while moving it out of target
+	if id, ok := strings.CutPrefix(req.ID, sensitiveImportPrefix); ok {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("sensitive_target"), "")...)
+		return
+	}
+
 	// Retrieve import ID and save to id attribute
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
 
+// ValidateConfig checks the record before hitting the server. Every check is
+// run so that all the issues are reported at once.
+func (r *recordResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config recordResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.Name.IsNull() && !config.Name.IsUnknown() {
+		normalizedName, err := usgdns.NormalizeName(config.Name.ValueString())
+		if err == nil {
+			err = usgdns.ValidateName(normalizedName)
+		}
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("name"),
+				"Invalid usg-dns record name",
+				err.Error(),
+			)
+		}
+	}
+
+	// The target value is kept out of the messages as it may be sensitive
+	targetPath, target := path.Root("target"), config.Target
+	if !config.SensitiveTarget.IsNull() {
+		targetPath, target = path.Root("sensitive_target"), config.SensitiveTarget
+	}
+
+	switch {
+	case !config.Target.IsNull() && !config.SensitiveTarget.IsNull():
+		resp.Diagnostics.AddAttributeError(
+			path.Root("sensitive_target"),
+			"Conflicting usg-dns record targets",
+			"Only one of target or sensitive_target can be set.",
+		)
+	case target.IsNull():
+		resp.Diagnostics.AddAttributeError(
+			path.Root("target"),
+			"Missing usg-dns record target",
+			"One of target or sensitive_target must be set.",
+		)
+	case target.IsUnknown():
+		// The target is checked once known
+	case usgdns.ValidateTarget(target.ValueString()) != nil:
+		resp.Diagnostics.AddAttributeError(
+			targetPath,
+			"Invalid usg-dns record target",
+			"The target must be an IP address.",
+		)
+	case netip.MustParseAddr(target.ValueString()).IsUnspecified():
+		resp.Diagnostics.AddAttributeWarning(
+			targetPath,
+			"Unspecified usg-dns record target",
+			"The target is the unspecified address, the record will not resolve to any host.",
+		)
+	case netip.MustParseAddr(target.ValueString()).Is4In6():
+		resp.Diagnostics.AddAttributeWarning(
+			targetPath,
+			"IPv4-mapped usg-dns record target",
+			"The target is an IPv4-mapped IPv6 address, its IPv4 form is probably expected.",
+		)
+	}
+}
+
+// ModifyPlan computes the normalized name so it is visible in the plan.
+func (r *recordResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to compute when the resource is destroyed
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var name types.String
+	diags := req.Plan.GetAttribute(ctx, path.Root("name"), &name)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || name.IsUnknown() {
+		return
+	}
+
+	normalizedName, err := usgdns.NormalizeName(name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("name"),
+			"Invalid usg-dns record name",
+			err.Error(),
+		)
+		return
+	}
+
+	diags = resp.Plan.SetAttribute(ctx, path.Root("normalized_name"), normalizedName)
+	resp.Diagnostics.Append(diags...)
+}
+
 // Create creates the resource and sets the initial Terraform state.
 func (r *recordResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	// Retrieve values from plan
@@ -96,9 +214,19 @@ func (r *recordResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	record, err := r.client.CreateRecord(plan.Name.ValueString(), plan.Target.ValueString())
+	normalizedName, err := usgdns.NormalizeName(plan.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.AddAttributeError(
+			path.Root("name"),
+			"Invalid usg-dns record name",
+			err.Error(),
+		)
+		return
+	}
+
+	record, err := r.client.CreateRecord(ctx, normalizedName, plan.target())
+	if err != nil {
+		addClientError(&resp.Diagnostics, err,
 			"Unable to create the usg-dns record",
 			err.Error(),
 		)
@@ -107,8 +235,8 @@ func (r *recordResource) Create(ctx context.Context, req resource.CreateRequest,
 
 	// Map response body to schema and populate Computed attribute values
 	plan.ID = types.StringValue(record.ID)
-	plan.Name = types.StringValue(record.Name)
-	plan.Target = types.StringValue(record.Target)
+	plan.NormalizedName = types.StringValue(record.Name)
+	plan.setTarget(record.Target)
 
 	// Set state to fully populated data
 	diags = resp.State.Set(ctx, plan)
@@ -128,18 +256,22 @@ func (r *recordResource) Read(ctx context.Context, req resource.ReadRequest, res
 	}
 
 	// Get refreshed record value from usg-dns
-	record, err := r.client.GetRecord(state.ID.ValueString())
+	record, err := r.client.GetRecord(ctx, state.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		addClientError(&resp.Diagnostics, err,
 			"Error Reading usg-dns record",
 			"Could not read usg-dns record ID "+state.ID.ValueString()+": "+err.Error(),
 		)
 		return
 	}
 
-	// Overwrite items with refreshed state
-	state.Name = types.StringValue(record.Name)
-	state.Target = types.StringValue(record.Target)
+	// Overwrite items with refreshed state, keeping the configured name
+	// as long as it still normalizes to the name known by the server
+	if normalizedName, err := usgdns.NormalizeName(state.Name.ValueString()); state.Name.IsNull() || err != nil || normalizedName != record.Name {
+		state.Name = types.StringValue(record.Name)
+	}
+	state.NormalizedName = types.StringValue(record.Name)
+	state.setTarget(record.Target)
 
 	// Set refreshed state
 	diags = resp.State.Set(ctx, &state)
@@ -165,12 +297,20 @@ func (r *recordResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	tflog.Info(ctx, "plan:", map[string]any{"plan": state})
+	normalizedName, err := usgdns.NormalizeName(plan.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("name"),
+			"Invalid usg-dns record name",
+			err.Error(),
+		)
+		return
+	}
 
 	// Update existing record
-	record, err := r.client.UpdateRecord(state.ID.ValueString(), plan.Name.ValueString(), plan.Target.ValueString())
+	record, err := r.client.UpdateRecord(ctx, state.ID.ValueString(), normalizedName, plan.target())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		addClientError(&resp.Diagnostics, err,
 			"Error Updating usg-dns record",
 			"Could not update record, unexpected error: "+err.Error(),
 		)
@@ -179,8 +319,8 @@ func (r *recordResource) Update(ctx context.Context, req resource.UpdateRequest,
 
 	// Update resource state with updated items and timestamp
 	plan.ID = types.StringValue(record.ID)
-	plan.Name = types.StringValue(record.Name)
-	plan.Target = types.StringValue(record.Target)
+	plan.NormalizedName = types.StringValue(record.Name)
+	plan.setTarget(record.Target)
 
 	// Set refreshed state
 	diags = resp.State.Set(ctx, plan)
@@ -201,9 +341,9 @@ func (r *recordResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	}
 
 	// Delete existing record
-	err := r.client.DeleteRecord(state.ID.ValueString())
+	err := r.client.DeleteRecord(ctx, state.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
+		addClientError(&resp.Diagnostics, err,
 			"Error Deleting usg-dns record",
 			"Could not delete record, unexpected error: "+err.Error(),
 		)
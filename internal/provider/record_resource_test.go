@@ -0,0 +1,327 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestRecordResourceModifyPlanNormalizedName(t *testing.T) {
+	tests := map[string]string{
+		"Mixed-Case.lan":  "Mixed-Case.lan",
+		"example.lan.":    "example.lan",
+		"Bücher.Example.": "xn--bcher-kva.example",
+	}
+
+	for name, want := range tests {
+		r, schemaResp := newRecordResource(t, "")
+
+		plan := tfsdk.Plan{
+			Schema: schemaResp.Schema,
+			Raw: objectValue(t, schemaResp.Schema, map[string]tftypes.Value{
+				"id":              tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+				"name":            stringValue(name),
+				"normalized_name": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+				"target":          stringValue("10.0.0.1"),
+			}),
+		}
+		req := resource.ModifyPlanRequest{Plan: plan}
+		resp := resource.ModifyPlanResponse{Plan: plan}
+
+		r.ModifyPlan(context.Background(), req, &resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("%s: unexpected diagnostics: %v", name, resp.Diagnostics)
+		}
+
+		var got types.String
+		resp.Plan.GetAttribute(context.Background(), path.Root("normalized_name"), &got)
+		if got.ValueString() != want {
+			t.Errorf("%s: expected normalized_name %q, got %q", name, want, got.ValueString())
+		}
+	}
+}
+
+func TestRecordResourceCreateNormalizedName(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	server := newJSONServer(t, http.StatusCreated, map[string]string{
+		"id":     "1",
+		"name":   "xn--bcher-kva.example",
+		"target": "10.0.0.1",
+	}, requests)
+	r, schemaResp := newRecordResource(t, server.URL)
+
+	plan := tfsdk.Plan{
+		Schema: schemaResp.Schema,
+		Raw: objectValue(t, schemaResp.Schema, map[string]tftypes.Value{
+			"id":              tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			"name":            stringValue("Bücher.Example."),
+			"normalized_name": stringValue("xn--bcher-kva.example"),
+			"target":          stringValue("10.0.0.1"),
+		}),
+	}
+	resp := resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+
+	r.Create(context.Background(), resource.CreateRequest{Plan: plan}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+	<-requests
+
+	var state recordResourceModel
+	resp.State.Get(context.Background(), &state)
+	if state.Name.ValueString() != "Bücher.Example." {
+		t.Errorf("the name should keep the configured value, got %q", state.Name.ValueString())
+	}
+	if state.NormalizedName.ValueString() != "xn--bcher-kva.example" {
+		t.Errorf("unexpected normalized_name %q", state.NormalizedName.ValueString())
+	}
+}
+
+func TestRecordResourceReadMixedCase(t *testing.T) {
+	server := newJSONServer(t, http.StatusOK, map[string]string{
+		"id":     "1",
+		"name":   "Mixed-Case.lan",
+		"target": "10.0.0.1",
+	}, nil)
+	r, schemaResp := newRecordResource(t, server.URL)
+
+	// State written before normalized_name existed
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw: objectValue(t, schemaResp.Schema, map[string]tftypes.Value{
+			"id":     stringValue("1"),
+			"name":   stringValue("Mixed-Case.lan"),
+			"target": stringValue("10.0.0.1"),
+		}),
+	}
+	resp := resource.ReadResponse{State: state}
+
+	r.Read(context.Background(), resource.ReadRequest{State: state}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	var got recordResourceModel
+	resp.State.Get(context.Background(), &got)
+	if got.Name.ValueString() != "Mixed-Case.lan" || got.NormalizedName.ValueString() != "Mixed-Case.lan" {
+		t.Errorf("the mixed-case name should be kept as is, got name %q and normalized_name %q", got.Name.ValueString(), got.NormalizedName.ValueString())
+	}
+}
+
+func TestRecordResourceSensitiveTarget(t *testing.T) {
+	_, schemaResp := newRecordResource(t, "")
+
+	// Terraform masks the sensitive attributes in the plan output
+	if !schemaResp.Schema.Attributes["sensitive_target"].IsSensitive() {
+		t.Error("sensitive_target should be masked in the plan output")
+	}
+	if schemaResp.Schema.Attributes["target"].IsSensitive() {
+		t.Error("target should be shown in the plan output")
+	}
+}
+
+func TestRecordResourceValidateConfig(t *testing.T) {
+	tests := map[string]struct {
+		values   map[string]tftypes.Value
+		errors   []path.Path
+		warnings []path.Path
+	}{
+		"valid": {
+			values: map[string]tftypes.Value{"name": stringValue("ok.lan"), "target": stringValue("10.0.0.1")},
+		},
+		"invalid name and target": {
+			values: map[string]tftypes.Value{"name": stringValue("bad_name.lan"), "target": stringValue("not-an-ip")},
+			errors: []path.Path{path.Root("name"), path.Root("target")},
+		},
+		"invalid sensitive target": {
+			values: map[string]tftypes.Value{"name": stringValue("ok.lan"), "sensitive_target": stringValue("secret-value")},
+			errors: []path.Path{path.Root("sensitive_target")},
+		},
+		"unspecified target": {
+			values:   map[string]tftypes.Value{"name": stringValue("ok.lan"), "target": stringValue("0.0.0.0")},
+			warnings: []path.Path{path.Root("target")},
+		},
+		"IPv4-mapped target": {
+			values:   map[string]tftypes.Value{"name": stringValue("ok.lan"), "sensitive_target": stringValue("::ffff:10.0.0.1")},
+			warnings: []path.Path{path.Root("sensitive_target")},
+		},
+	}
+
+	for name, test := range tests {
+		r, schemaResp := newRecordResource(t, "")
+
+		config := tfsdk.Config{Schema: schemaResp.Schema, Raw: objectValue(t, schemaResp.Schema, test.values)}
+		var resp resource.ValidateConfigResponse
+		r.ValidateConfig(context.Background(), resource.ValidateConfigRequest{Config: config}, &resp)
+
+		var errors, warnings []path.Path
+		for _, d := range resp.Diagnostics {
+			withPath, ok := d.(diag.DiagnosticWithPath)
+			if !ok {
+				t.Errorf("%s: unexpected diagnostic without path: %v", name, d)
+				continue
+			}
+			if d.Severity() == diag.SeverityError {
+				errors = append(errors, withPath.Path())
+			} else {
+				warnings = append(warnings, withPath.Path())
+			}
+			// The target may be sensitive and must not be part of the messages
+			if strings.Contains(d.Detail(), "secret-value") {
+				t.Errorf("%s: the target leaked in the diagnostic %q", name, d.Detail())
+			}
+		}
+
+		if !slices.EqualFunc(errors, test.errors, path.Path.Equal) {
+			t.Errorf("%s: expected the errors on %v, got %v", name, test.errors, errors)
+		}
+		if !slices.EqualFunc(warnings, test.warnings, path.Path.Equal) {
+			t.Errorf("%s: expected the warnings on %v, got %v", name, test.warnings, warnings)
+		}
+	}
+}
+
+func TestRecordResourceImportState(t *testing.T) {
+	tests := map[string]struct {
+		target          string
+		sensitiveTarget string
+	}{
+		"1":           {target: "10.0.0.1"},
+		"sensitive:1": {sensitiveTarget: "10.0.0.1"},
+	}
+
+	for importID, test := range tests {
+		server := newJSONServer(t, http.StatusOK, map[string]string{
+			"id":     "1",
+			"name":   "imported.lan",
+			"target": "10.0.0.1",
+		}, nil)
+		r, schemaResp := newRecordResource(t, server.URL)
+
+		importResp := resource.ImportStateResponse{State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(context.Background()), nil),
+		}}
+		r.ImportState(context.Background(), resource.ImportStateRequest{ID: importID}, &importResp)
+		if importResp.Diagnostics.HasError() {
+			t.Fatalf("%s: unexpected diagnostics: %v", importID, importResp.Diagnostics)
+		}
+
+		readResp := resource.ReadResponse{State: importResp.State}
+		r.Read(context.Background(), resource.ReadRequest{State: importResp.State}, &readResp)
+		if readResp.Diagnostics.HasError() {
+			t.Fatalf("%s: unexpected diagnostics: %v", importID, readResp.Diagnostics)
+		}
+
+		var state recordResourceModel
+		readResp.State.Get(context.Background(), &state)
+		if state.ID.ValueString() != "1" {
+			t.Errorf("%s: unexpected ID %q", importID, state.ID.ValueString())
+		}
+		if state.Target.ValueString() != test.target || state.SensitiveTarget.ValueString() != test.sensitiveTarget {
+			t.Errorf("%s: expected target %q and sensitive_target %q, got %s and %s",
+				importID, test.target, test.sensitiveTarget, state.Target, state.SensitiveTarget)
+		}
+		if test.target == "" && !state.Target.IsNull() {
+			t.Errorf("%s: target should stay null", importID)
+		}
+	}
+}
+
+func TestRecordResourceSensitiveTargetRoundTrip(t *testing.T) {
+	// The server stores the record sent by the last create or update
+	var mu sync.Mutex
+	stored := map[string]string{"id": "1"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		statusCode := http.StatusOK
+		switch r.Method {
+		case http.MethodPost:
+			statusCode = http.StatusCreated
+			fallthrough
+		case http.MethodPut:
+			var body map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("unable to decode the request body: %v", err)
+			}
+			stored["name"], stored["target"] = body["name"], body["target"]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_ = json.NewEncoder(w).Encode(stored)
+	}))
+	t.Cleanup(server.Close)
+
+	r, schemaResp := newRecordResource(t, server.URL)
+	ctx := context.Background()
+
+	// assertTargets checks the target is only held by sensitive_target
+	assertTargets := func(step string, state tfsdk.State, want string) {
+		t.Helper()
+
+		var got recordResourceModel
+		state.Get(ctx, &got)
+		if !got.Target.IsNull() {
+			t.Errorf("%s: target should stay null, got %s", step, got.Target)
+		}
+		if got.SensitiveTarget.ValueString() != want {
+			t.Errorf("%s: expected sensitive_target %q, got %s", step, want, got.SensitiveTarget)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if stored["target"] != want {
+			t.Errorf("%s: expected the server to hold the target %q, got %q", step, want, stored["target"])
+		}
+	}
+
+	plan := func(sensitiveTarget string) tfsdk.Plan {
+		return tfsdk.Plan{
+			Schema: schemaResp.Schema,
+			Raw: objectValue(t, schemaResp.Schema, map[string]tftypes.Value{
+				"id":               tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+				"name":             stringValue("secret.lan"),
+				"normalized_name":  stringValue("secret.lan"),
+				"sensitive_target": stringValue(sensitiveTarget),
+			}),
+		}
+	}
+
+	createResp := resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(ctx, resource.CreateRequest{Plan: plan("10.0.0.1")}, &createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("create: unexpected diagnostics: %v", createResp.Diagnostics)
+	}
+	assertTargets("create", createResp.State, "10.0.0.1")
+
+	readResp := resource.ReadResponse{State: createResp.State}
+	r.Read(ctx, resource.ReadRequest{State: createResp.State}, &readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("read: unexpected diagnostics: %v", readResp.Diagnostics)
+	}
+	assertTargets("read", readResp.State, "10.0.0.1")
+
+	updateResp := resource.UpdateResponse{State: readResp.State}
+	r.Update(ctx, resource.UpdateRequest{Plan: plan("10.0.0.2"), State: readResp.State}, &updateResp)
+	if updateResp.Diagnostics.HasError() {
+		t.Fatalf("update: unexpected diagnostics: %v", updateResp.Diagnostics)
+	}
+	assertTargets("update", updateResp.State, "10.0.0.2")
+}
@@ -22,7 +22,7 @@ var (
 
 // recordsDataSourceModel maps the data source schema data.
 type recordsDataSourceModel struct {
-	Records []recordResourceModel `tfsdk:"records"`
+	Records []recordModel `tfsdk:"records"`
 }
 
 func NewRecordsDataSource() datasource.DataSource {
@@ -88,9 +88,9 @@ func (d *recordsDataSource) Configure(_ context.Context, req datasource.Configur
 func (d *recordsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var state recordsDataSourceModel
 
-	records, err := d.client.GetRecords()
+	records, err := d.client.GetRecords(ctx)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		addClientError(&resp.Diagnostics, err,
 			"Unable to fetch the usg-dns records",
 			err.Error(),
 		)
@@ -99,7 +99,7 @@ func (d *recordsDataSource) Read(ctx context.Context, req datasource.ReadRequest
 
 	// Map response body to model
 	for _, record := range records {
-		recordState := recordResourceModel{
+		recordState := recordModel{
 			ID:     types.StringValue(record.ID),
 			Name:   types.StringValue(record.Name),
 			Target: types.StringValue(record.Target),
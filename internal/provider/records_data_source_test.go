@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+
+	"terraform-provider-usgdns/internal/usgdns"
+)
+
+// managedPrefixClient returns a client scoped to the "app-" managed prefix,
+// talking to a server holding records inside and outside of it.
+func managedPrefixClient(t *testing.T) *usgdns.Client {
+	t.Helper()
+
+	server := newJSONServer(t, http.StatusOK, []map[string]string{
+		{"id": "1", "name": "app-kept.lan", "target": "10.0.0.1"},
+		{"id": "2", "name": "APP-upper.lan", "target": "10.0.0.2"},
+		{"id": "3", "name": "other.lan", "target": "10.0.0.3"},
+	}, nil)
+
+	client, err := usgdns.NewClient(server.URL, "token", usgdns.WithManagedPrefix("app-"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// recordModelNames returns the names of the records, in order.
+func recordModelNames(models []recordModel) []string {
+	names := make([]string, 0, len(models))
+	for _, model := range models {
+		names = append(names, model.Name.ValueString())
+	}
+	return names
+}
+
+func TestRecordsDataSourceManagedPrefix(t *testing.T) {
+	d := &recordsDataSource{client: managedPrefixClient(t)}
+	var schemaResp datasource.SchemaResponse
+	d.Schema(context.Background(), datasource.SchemaRequest{}, &schemaResp)
+
+	config := tfsdk.Config{Schema: schemaResp.Schema, Raw: objectValue(t, schemaResp.Schema, nil)}
+	resp := datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+
+	d.Read(context.Background(), datasource.ReadRequest{Config: config}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	var state recordsDataSourceModel
+	resp.State.Get(context.Background(), &state)
+
+	// other.lan is outside of the managed prefix
+	if got := recordModelNames(state.Records); !slices.Equal(got, []string{"app-kept.lan", "APP-upper.lan"}) {
+		t.Errorf("unexpected records: %v", got)
+	}
+}
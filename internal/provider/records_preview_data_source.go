@@ -0,0 +1,209 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	usgdnsdb "github.com/rclsilver-org/usg-dns-api/db"
+
+	"terraform-provider-usgdns/internal/usgdns"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &recordsPreviewDataSource{}
+	_ datasource.DataSourceWithConfigure = &recordsPreviewDataSource{}
+)
+
+// recordsPreviewDataSourceModel maps the data source schema data.
+type recordsPreviewDataSourceModel struct {
+	Records  []recordSpecModel     `tfsdk:"records"`
+	Create   []recordModel         `tfsdk:"create"`
+	Update   []recordModel         `tfsdk:"update"`
+	Delete   []recordModel         `tfsdk:"delete"`
+	Rejected []recordRejectedModel `tfsdk:"rejected"`
+}
+
+// recordRejectedModel maps a desired record refused by the validation.
+type recordRejectedModel struct {
+	Name   types.String `tfsdk:"name"`
+	Target types.String `tfsdk:"target"`
+	Reason types.String `tfsdk:"reason"`
+}
+
+func NewRecordsPreviewDataSource() datasource.DataSource {
+	return &recordsPreviewDataSource{}
+}
+
+type recordsPreviewDataSource struct {
+	client *usgdns.Client
+}
+
+func (d *recordsPreviewDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_records_preview"
+}
+
+func (d *recordsPreviewDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	recordAttributes := map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			Computed:    true,
+			Description: "Identifier of the existing record, null for records to create.",
+		},
+		"name": schema.StringAttribute{
+			Computed:    true,
+			Description: "Name of the record.",
+		},
+		"target": schema.StringAttribute{
+			Computed:    true,
+			Description: "Target of the record.",
+		},
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Preview the changes needed to reach a full set of records, without mutating anything.",
+		Attributes: map[string]schema.Attribute{
+			"records": schema.ListNestedAttribute{
+				Required:    true,
+				Description: "Desired records.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Required:    true,
+							Description: "Name of the record.",
+						},
+						"target": schema.StringAttribute{
+							Required:    true,
+							Description: "Target of the record.",
+						},
+					},
+				},
+			},
+			"create": schema.ListNestedAttribute{
+				Computed:     true,
+				Description:  "Records which would be created.",
+				NestedObject: schema.NestedAttributeObject{Attributes: recordAttributes},
+			},
+			"update": schema.ListNestedAttribute{
+				Computed:     true,
+				Description:  "Records which would be updated, with their desired target.",
+				NestedObject: schema.NestedAttributeObject{Attributes: recordAttributes},
+			},
+			"delete": schema.ListNestedAttribute{
+				Computed:     true,
+				Description:  "Existing records which are not part of the desired records.",
+				NestedObject: schema.NestedAttributeObject{Attributes: recordAttributes},
+			},
+			"rejected": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "Desired records which would be refused by the server.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Computed:    true,
+							Description: "Name of the record.",
+						},
+						"target": schema.StringAttribute{
+							Computed:    true,
+							Description: "Target of the record.",
+						},
+						"reason": schema.StringAttribute{
+							Computed:    true,
+							Description: "Reason of the rejection.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *recordsPreviewDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*usgdns.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *usgdns.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *recordsPreviewDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state recordsPreviewDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	desired := make([]usgdnsdb.Record, 0, len(state.Records))
+	for _, record := range state.Records {
+		desired = append(desired, usgdnsdb.Record{
+			Name:   record.Name.ValueString(),
+			Target: record.Target.ValueString(),
+		})
+	}
+
+	preview, err := d.client.PreviewRecords(ctx, desired)
+	if err != nil {
+		addClientError(&resp.Diagnostics, err,
+			"Unable to preview the usg-dns records",
+			err.Error(),
+		)
+		return
+	}
+
+	// Map the preview to model
+	state.Create = recordModels(preview.Create)
+	state.Update = recordModels(preview.Update)
+	state.Delete = recordModels(preview.Delete)
+	state.Rejected = []recordRejectedModel{}
+	for _, rejected := range preview.Rejected {
+		state.Rejected = append(state.Rejected, recordRejectedModel{
+			Name:   types.StringValue(rejected.Record.Name),
+			Target: types.StringValue(rejected.Record.Target),
+			Reason: types.StringValue(rejected.Reason),
+		})
+	}
+
+	// Set state
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// recordModels maps API records to models, leaving the ID null for records
+// which do not exist yet.
+func recordModels(records []usgdnsdb.Record) []recordModel {
+	models := []recordModel{}
+	for _, record := range records {
+		id := types.StringNull()
+		if record.ID != "" {
+			id = types.StringValue(record.ID)
+		}
+		models = append(models, recordModel{
+			ID:     id,
+			Name:   types.StringValue(record.Name),
+			Target: types.StringValue(record.Target),
+		})
+	}
+	return models
+}
@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestRecordsPreviewDataSourceManagedPrefix(t *testing.T) {
+	d := &recordsPreviewDataSource{client: managedPrefixClient(t)}
+	var schemaResp datasource.SchemaResponse
+	d.Schema(context.Background(), datasource.SchemaRequest{}, &schemaResp)
+
+	recordsType, ok := schemaResp.Schema.Attributes["records"].GetType().TerraformType(context.Background()).(tftypes.List)
+	if !ok {
+		t.Fatal("unexpected records type")
+	}
+	records := []tftypes.Value{}
+	for _, name := range []string{"app-kept.lan", "app-added.lan", "other-added.lan"} {
+		records = append(records, tftypes.NewValue(recordsType.ElementType, map[string]tftypes.Value{
+			"name":   stringValue(name),
+			"target": stringValue("10.0.0.1"),
+		}))
+	}
+
+	config := tfsdk.Config{
+		Schema: schemaResp.Schema,
+		Raw: objectValue(t, schemaResp.Schema, map[string]tftypes.Value{
+			"records": tftypes.NewValue(recordsType, records),
+		}),
+	}
+	resp := datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+
+	d.Read(context.Background(), datasource.ReadRequest{Config: config}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	var state recordsPreviewDataSourceModel
+	resp.State.Get(context.Background(), &state)
+
+	if got := recordModelNames(state.Create); !slices.Equal(got, []string{"app-added.lan"}) {
+		t.Errorf("unexpected records to create: %v", got)
+	}
+	// other.lan is outside of the managed prefix and must not be deleted
+	if got := recordModelNames(state.Delete); !slices.Equal(got, []string{"APP-upper.lan"}) {
+		t.Errorf("unexpected records to delete: %v", got)
+	}
+	if len(state.Rejected) != 1 || state.Rejected[0].Name.ValueString() != "other-added.lan" {
+		t.Errorf("the record outside of the managed prefix should be rejected, got %v", state.Rejected)
+	}
+}
@@ -5,9 +5,42 @@ package provider
 
 import "github.com/hashicorp/terraform-plugin-framework/types"
 
-// recordResourceModel maps records schema data.
+// recordResourceModel maps record resource schema data.
 type recordResourceModel struct {
+	ID              types.String `tfsdk:"id"`
+	Name            types.String `tfsdk:"name"`
+	NormalizedName  types.String `tfsdk:"normalized_name"`
+	Target          types.String `tfsdk:"target"`
+	SensitiveTarget types.String `tfsdk:"sensitive_target"`
+}
+
+// target returns the record target, whichever attribute holds it.
+func (m *recordResourceModel) target() string {
+	if !m.SensitiveTarget.IsNull() {
+		return m.SensitiveTarget.ValueString()
+	}
+	return m.Target.ValueString()
+}
+
+// setTarget sets the record target in the attribute in use, defaulting to
+// target when none is set yet, e.g. on import without the sensitive prefix.
+func (m *recordResourceModel) setTarget(target string) {
+	if !m.SensitiveTarget.IsNull() {
+		m.SensitiveTarget = types.StringValue(target)
+		return
+	}
+	m.Target = types.StringValue(target)
+}
+
+// recordModel maps records schema data in the data sources.
+type recordModel struct {
 	ID     types.String `tfsdk:"id"`
 	Name   types.String `tfsdk:"name"`
 	Target types.String `tfsdk:"target"`
 }
+
+// recordSpecModel maps a record by its name and target only.
+type recordSpecModel struct {
+	Name   types.String `tfsdk:"name"`
+	Target types.String `tfsdk:"target"`
+}
@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// specPath is the path of the OpenAPI specification served by the server.
+const specPath = "/spec.json"
+
+// Capabilities describes the optional features of the server, so the client
+// only falls back when needed without probing on every call.
+type Capabilities struct {
+	// RecordGet tells whether the single record endpoint is implemented.
+	RecordGet bool
+}
+
+// Capabilities returns the server capabilities, discovering them on the
+// first call only.
+func (c *Client) Capabilities(ctx context.Context) Capabilities {
+	c.capabilitiesOnce.Do(func() {
+		c.setCapabilities(c.discoverCapabilities(ctx))
+	})
+
+	c.capabilitiesMu.Lock()
+	defer c.capabilitiesMu.Unlock()
+
+	return c.capabilities
+}
+
+// RefreshCapabilities discovers the server capabilities again.
+func (c *Client) RefreshCapabilities(ctx context.Context) Capabilities {
+	capabilities := c.discoverCapabilities(ctx)
+
+	// Wait for a running first discovery, and prevent a later one, so the
+	// refreshed capabilities are not overwritten
+	c.capabilitiesOnce.Do(func() {})
+	c.setCapabilities(capabilities)
+
+	return capabilities
+}
+
+// setCapabilities replaces the cached capabilities.
+func (c *Client) setCapabilities(capabilities Capabilities) {
+	c.capabilitiesMu.Lock()
+	defer c.capabilitiesMu.Unlock()
+
+	c.capabilities = capabilities
+}
+
+// disableRecordGet records that the single record endpoint is missing, as
+// inferred from a response.
+func (c *Client) disableRecordGet() {
+	c.capabilitiesMu.Lock()
+	defer c.capabilitiesMu.Unlock()
+
+	c.capabilities.RecordGet = false
+}
+
+// discoverCapabilities reads the capabilities from the OpenAPI specification
+// of the server. Every feature is assumed to be available when the
+// specification cannot be fetched, and disabled later from the responses.
+func (c *Client) discoverCapabilities(ctx context.Context) Capabilities {
+	capabilities := Capabilities{
+		RecordGet: true,
+	}
+
+	res, err := c.do(ctx, http.MethodGet, specPath, nil)
+	if err != nil || checkStatus(res, http.StatusOK) != nil {
+		return capabilities
+	}
+
+	var spec struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	if err := unmarshal(res, &spec); err != nil || spec.Paths == nil {
+		return capabilities
+	}
+
+	capabilities.RecordGet = false
+	for apiPath, operations := range spec.Paths {
+		if !strings.HasPrefix(apiPath, recordsPath+"/{") {
+			continue
+		}
+		if _, ok := operations["get"]; ok {
+			capabilities.RecordGet = true
+		}
+	}
+
+	return capabilities
+}
@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// Specifications of the servers with and without the single record endpoint.
+var (
+	specWithRecordGet = map[string]map[string]any{
+		recordsPath:                  {"get": map[string]any{}, "post": map[string]any{}},
+		recordsPath + "/{record_id}": {"get": map[string]any{}, "put": map[string]any{}, "delete": map[string]any{}},
+	}
+	specWithoutRecordGet = map[string]map[string]any{
+		recordsPath:                  {"get": map[string]any{}, "post": map[string]any{}},
+		recordsPath + "/{record_id}": {"put": map[string]any{}, "delete": map[string]any{}},
+	}
+)
+
+func TestCapabilitiesDiscoveredOnce(t *testing.T) {
+	server := newFakeServer(t, newRecord("1", "first.lan", "10.0.0.1"))
+	server.spec = specWithoutRecordGet
+
+	client, err := NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if client.Capabilities(context.Background()).RecordGet {
+				t.Error("the single record endpoint should be detected as missing")
+			}
+		}()
+	}
+	wg.Wait()
+
+	for range 2 {
+		record, err := client.GetRecord(context.Background(), "1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if record.Name != "first.lan" {
+			t.Errorf("unexpected record %q", record.Name)
+		}
+	}
+
+	if count := server.requestCount(http.MethodGet, specPath); count != 1 {
+		t.Errorf("expected the specification to be fetched once, got %d", count)
+	}
+
+	// The fallback is used without probing the missing endpoint
+	if count := server.requestCount(http.MethodGet, recordsPath+"/1"); count != 0 {
+		t.Errorf("expected no request to the single record endpoint, got %d", count)
+	}
+	if count := server.requestCount(http.MethodGet, recordsPath); count != 2 {
+		t.Errorf("expected 2 requests to the records list, got %d", count)
+	}
+}
+
+func TestCapabilitiesWithoutSpec(t *testing.T) {
+	server := newFakeServer(t)
+
+	client, err := NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !client.Capabilities(context.Background()).RecordGet {
+		t.Error("the single record endpoint should be assumed available without specification")
+	}
+}
+
+func TestRefreshCapabilities(t *testing.T) {
+	server := newFakeServer(t)
+	server.spec = specWithRecordGet
+
+	client, err := NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !client.Capabilities(context.Background()).RecordGet {
+		t.Fatal("the single record endpoint should be detected")
+	}
+
+	server.mu.Lock()
+	server.spec = specWithoutRecordGet
+	server.mu.Unlock()
+
+	if !client.Capabilities(context.Background()).RecordGet {
+		t.Error("the capabilities should be cached until refreshed")
+	}
+	if client.RefreshCapabilities(context.Background()).RecordGet {
+		t.Error("the refreshed capabilities should not include the single record endpoint")
+	}
+	if client.Capabilities(context.Background()).RecordGet {
+		t.Error("the refreshed capabilities should be cached")
+	}
+
+	if count := server.requestCount(http.MethodGet, specPath); count != 2 {
+		t.Errorf("expected the specification to be fetched twice, got %d", count)
+	}
+}
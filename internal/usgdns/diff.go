@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import (
+	usgdns "github.com/rclsilver-org/usg-dns-api/db"
+)
+
+// RecordChanges lists the operations needed to go from a set of records to another.
+type RecordChanges struct {
+	Create []usgdns.Record
+	Update []usgdns.Record
+	Delete []usgdns.Record
+}
+
+// DiffRecords compares the desired records with the actual ones. Records are
+// matched by name, which is unique on the server side. Updated records carry
+// the ID of the actual record and the desired target.
+func DiffRecords(desired, actual []usgdns.Record) RecordChanges {
+	var changes RecordChanges
+
+	actualByName := make(map[string]usgdns.Record, len(actual))
+	for _, record := range actual {
+		actualByName[record.Name] = record
+	}
+
+	desiredNames := make(map[string]struct{}, len(desired))
+	for _, record := range desired {
+		desiredNames[record.Name] = struct{}{}
+
+		existing, ok := actualByName[record.Name]
+		if !ok {
+			changes.Create = append(changes.Create, record)
+			continue
+		}
+		if existing.Target != record.Target {
+			record.ID = existing.ID
+			changes.Update = append(changes.Update, record)
+		}
+	}
+
+	for _, record := range actual {
+		if _, ok := desiredNames[record.Name]; !ok {
+			changes.Delete = append(changes.Delete, record)
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import (
+	"slices"
+	"testing"
+
+	usgdns "github.com/rclsilver-org/usg-dns-api/db"
+)
+
+func TestDiffRecords(t *testing.T) {
+	desired := []usgdns.Record{
+		newRecord("", "kept.lan", "10.0.0.1"),
+		newRecord("", "changed.lan", "10.0.0.20"),
+		newRecord("", "added.lan", "10.0.0.3"),
+	}
+	actual := []usgdns.Record{
+		newRecord("1", "kept.lan", "10.0.0.1"),
+		newRecord("2", "changed.lan", "10.0.0.2"),
+		newRecord("4", "removed.lan", "10.0.0.4"),
+	}
+
+	changes := DiffRecords(desired, actual)
+
+	if got := recordNames(changes.Create); !slices.Equal(got, []string{"added.lan"}) {
+		t.Errorf("unexpected records to create: %v", got)
+	}
+	if got := recordNames(changes.Update); !slices.Equal(got, []string{"changed.lan"}) {
+		t.Errorf("unexpected records to update: %v", got)
+	} else if changes.Update[0].ID != "2" || changes.Update[0].Target != "10.0.0.20" {
+		t.Errorf("the updated record should carry the actual ID and the desired target, got %+v", changes.Update[0])
+	}
+	if got := recordNames(changes.Delete); !slices.Equal(got, []string{"removed.lan"}) {
+		t.Errorf("unexpected records to delete: %v", got)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	usgdns "github.com/rclsilver-org/usg-dns-api/db"
+)
+
+// fakeServer is a minimal in-memory usg-dns-api server.
+type fakeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	records  []usgdns.Record
+	requests []*http.Request
+
+	// noRecordGet makes the server answer the single record requests like
+	// the servers lacking the endpoint.
+	noRecordGet bool
+
+	// spec holds the paths of the OpenAPI specification, which is not
+	// served when nil.
+	spec map[string]map[string]any
+}
+
+func newFakeServer(t *testing.T, records ...usgdns.Record) *fakeServer {
+	t.Helper()
+
+	s := &fakeServer{records: records}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// requestCount returns the number of requests received for the method and path.
+func (s *fakeServer) requestCount(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, req := range s.requests {
+		if req.Method == method && req.URL.Path == path {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *fakeServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r)
+
+	id, hasID := strings.CutPrefix(r.URL.Path, recordsPath+"/")
+	switch {
+	case r.URL.Path == recordsPath && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.records)
+	case r.URL.Path == recordsPath && r.Method == http.MethodPost:
+		var body recordBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
+		record := usgdns.Record{Name: body.Name, Target: body.Target}
+		record.ID = "id-" + body.Name
+		s.records = append(s.records, record)
+		writeJSON(w, http.StatusCreated, record)
+	case r.URL.Path == specPath && r.Method == http.MethodGet && s.spec != nil:
+		writeJSON(w, http.StatusOK, map[string]any{"paths": s.spec})
+	case hasID && r.Method == http.MethodGet && s.noRecordGet:
+		http.NotFound(w, r)
+	case hasID && r.Method == http.MethodGet:
+		for _, record := range s.records {
+			if record.ID == id {
+				writeJSON(w, http.StatusOK, record)
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "no record found with this ID"})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"message": "method not allowed"})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// newRecord builds a record, the usg-dns-api type embedding its ID.
+func newRecord(id, name, target string) usgdns.Record {
+	record := usgdns.Record{Name: name, Target: target}
+	record.ID = id
+	return record
+}
+
+// recordNames returns the names of the records, in order.
+func recordNames(records []usgdns.Record) []string {
+	names := make([]string, 0, len(records))
+	for _, record := range records {
+		names = append(names, record.Name)
+	}
+	return names
+}
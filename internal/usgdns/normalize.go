@@ -0,0 +1,39 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// NormalizeName returns the canonical form of a record name as it is sent to
+// the server: without the trailing dot and, for internationalized names,
+// IDNA encoded. ASCII names are kept as written, case included, as the
+// server accepts them as is.
+func NormalizeName(name string) (string, error) {
+	name = strings.TrimSuffix(name, ".")
+	if isASCII(name) {
+		return name, nil
+	}
+
+	normalized, err := idna.Lookup.ToASCII(name)
+	if err != nil {
+		return "", fmt.Errorf("unable to normalize the name %q: %w", name, err)
+	}
+	return normalized, nil
+}
+
+// isASCII reports whether the string only holds ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "example.lan", want: "example.lan"},
+		{name: "example.lan.", want: "example.lan"},
+		{name: "Mixed-Case.LAN", want: "Mixed-Case.LAN"},
+		{name: "Mixed-Case.LAN.", want: "Mixed-Case.LAN"},
+		{name: "bücher.lan", want: "xn--bcher-kva.lan"},
+		{name: "Bücher.LAN.", want: "xn--bcher-kva.lan"},
+		{name: "ünder_score.lan", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeName(tt.name)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NormalizeName(%q): expected an error, got %q", tt.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NormalizeName(%q): unexpected error: %v", tt.name, err)
+		} else if got != tt.want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxRetries is the number of times a request is retried when the
+	// server asks to come back later.
+	maxRetries = 3
+
+	// maxRetryWait caps the time waited before retrying a request, whatever
+	// the server asks for.
+	maxRetryWait = 30 * time.Second
+)
+
+// isRetryable reports whether the response asks to retry the request later,
+// and whether doing so is safe for the request method. A 429 is sent before
+// the request is processed. A 503 may come from a gateway after the server
+// processed the request, so the non idempotent requests are only retried
+// when it carries a Retry-After header, telling the request was refused.
+func isRetryable(res *http.Response, method string) bool {
+	switch res.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusServiceUnavailable:
+		return isIdempotent(method) || res.Header.Get("Retry-After") != ""
+	default:
+		return false
+	}
+}
+
+// isIdempotent reports whether sending the request several times has the
+// same effect as sending it once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryWait returns the time to wait before the next attempt. The Retry-After
+// header is honored when present, otherwise the wait grows exponentially.
+func retryWait(res *http.Response, attempt int) time.Duration {
+	wait, ok := parseRetryAfter(res.Header.Get("Retry-After"))
+	if !ok {
+		wait = time.Second << attempt
+	}
+	return min(wait, maxRetryWait)
+}
+
+// parseRetryAfter parses a Retry-After header value, either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
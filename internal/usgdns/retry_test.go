@@ -0,0 +1,130 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfterOn503(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, http.StatusOK, []any{})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if _, err := client.GetRecords(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("the Retry-After header was not honored, retried after %s", elapsed)
+	}
+}
+
+func TestRetryWaitCap(t *testing.T) {
+	for _, retryAfter := range []string{
+		"3600",
+		time.Now().Add(time.Hour).UTC().Format(http.TimeFormat),
+	} {
+		res := &http.Response{Header: http.Header{"Retry-After": []string{retryAfter}}}
+		if got := retryWait(res, 0); got != maxRetryWait {
+			t.Errorf("Retry-After %q: expected the wait to be capped to %s, got %s", retryAfter, maxRetryWait, got)
+		}
+	}
+
+	res := &http.Response{Header: http.Header{}}
+	if got := retryWait(res, 10); got != maxRetryWait {
+		t.Errorf("expected the backoff to be capped to %s, got %s", maxRetryWait, got)
+	}
+	if got := retryWait(res, 1); got != 2*time.Second {
+		t.Errorf("expected a 2s backoff, got %s", got)
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.GetRecords(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the context error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the cancellation did not interrupt the wait, returned after %s", elapsed)
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		retryAfter string
+		retried    bool
+	}{
+		{statusCode: http.StatusTooManyRequests, retried: true},
+		{statusCode: http.StatusServiceUnavailable, retryAfter: "0", retried: true},
+		// A gateway may answer 503 after the server created the record
+		{statusCode: http.StatusServiceUnavailable, retried: false},
+	}
+
+	for _, test := range tests {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				if test.retryAfter != "" {
+					w.Header().Set("Retry-After", test.retryAfter)
+				}
+				w.WriteHeader(test.statusCode)
+				return
+			}
+			writeJSON(w, http.StatusCreated, map[string]string{"id": "1", "name": "a.lan", "target": "10.0.0.1"})
+		}))
+
+		client, err := NewClient(server.URL, "token")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.CreateRecord(context.Background(), "a.lan", "10.0.0.1")
+		server.Close()
+
+		if test.retried && (err != nil || calls.Load() != 2) {
+			t.Errorf("%d Retry-After %q: expected the POST to be retried, got %d requests and %v", test.statusCode, test.retryAfter, calls.Load(), err)
+		}
+		if !test.retried && (err == nil || calls.Load() != 1) {
+			t.Errorf("%d Retry-After %q: expected the POST not to be retried, got %d requests and %v", test.statusCode, test.retryAfter, calls.Load(), err)
+		}
+	}
+}
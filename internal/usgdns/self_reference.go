@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// selfReferenceLookupTimeout bounds the resolution of the server host.
+const selfReferenceLookupTimeout = 5 * time.Second
+
+// checkSelfReference returns an error when the target is one of the addresses
+// of the usg-dns API server, which is most likely a mistake. The check is
+// skipped, with a warning, when the server host cannot be resolved in time.
+func (c *Client) checkSelfReference(ctx context.Context, name, target string) error {
+	// Invalid targets are left to the validation of the server
+	targetAddr, err := netip.ParseAddr(target)
+	if err == nil {
+		serverAddrs, host := c.resolveServerAddrs(ctx)
+		for _, serverAddr := range serverAddrs {
+			if serverAddr.Unmap() == targetAddr.Unmap() {
+				return fmt.Errorf("the target of the record %q points at the usg-dns API server %s", name, host)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveServerAddrs returns the addresses of the usg-dns API server, along
+// with its host. No address is returned when the host cannot be resolved.
+func (c *Client) resolveServerAddrs(ctx context.Context) ([]netip.Addr, string) {
+	parsedURL, err := url.Parse(c.url)
+	if err != nil {
+		tflog.Warn(ctx, "unable to parse the usg-dns API URL, skipping the self reference check", map[string]any{
+			"error": err.Error(),
+		})
+		return nil, ""
+	}
+	host := parsedURL.Hostname()
+
+	if hostAddr, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{hostAddr}, host
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, selfReferenceLookupTimeout)
+	defer cancel()
+
+	hostAddrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		tflog.Warn(ctx, "unable to resolve the usg-dns API server host, skipping the self reference check", map[string]any{
+			"host":  host,
+			"error": err.Error(),
+		})
+		return nil, host
+	}
+
+	return hostAddrs, host
+}
@@ -0,0 +1,63 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestForbidSelfReference(t *testing.T) {
+	// The test server URL host is an IP literal, so no resolution happens
+	server := newFakeServer(t)
+
+	client, err := NewClient(server.URL, "token", WithForbidSelfReference())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []string{"127.0.0.1", "::ffff:127.0.0.1"} {
+		_, err := client.CreateRecord(context.Background(), "self.lan", target)
+		if err == nil || !strings.Contains(err.Error(), "points at the usg-dns API server 127.0.0.1") {
+			t.Errorf("%s: expected a self reference error, got %v", target, err)
+		}
+	}
+	if _, err := client.UpdateRecord(context.Background(), "1", "self.lan", "127.0.0.1"); err == nil {
+		t.Error("expected a self reference error on update")
+	}
+	if count := server.requestCount(http.MethodPost, recordsPath); count != 0 {
+		t.Errorf("the self referencing records should not be sent, got %d requests", count)
+	}
+
+	record, err := client.CreateRecord(context.Background(), "other.lan", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("unexpected error for an unrelated target: %v", err)
+	}
+	if record.Target != "10.0.0.1" {
+		t.Errorf("unexpected target %q", record.Target)
+	}
+}
+
+func TestForbidSelfReferenceUnresolvedHost(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &logs)
+
+	client, err := NewClient("http://usg-dns.invalid", "token", WithForbidSelfReference())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The check is skipped, but not silently
+	if err := client.checkSelfReference(ctx, "other.lan", "10.0.0.1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !strings.Contains(logs.String(), "skipping the self reference check") {
+		t.Errorf("expected a warning about the skipped check, got %q", logs.String())
+	}
+}
@@ -5,145 +5,393 @@ package usgdns
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	usgdns "github.com/rclsilver-org/usg-dns-api/db"
 )
 
+// ErrTokenNotYetValid is returned when the server refuses a time-bound token
+// which is not valid yet, usually because the local clock is ahead.
+var ErrTokenNotYetValid = errors.New("token not yet valid")
+
+// ErrHTMLResponse is returned when the server answers with an HTML page,
+// usually because the URL points at a web UI instead of the API.
+var ErrHTMLResponse = errors.New("the server answered with an HTML page instead of JSON")
+
+// IDFields lists the response fields which may hold the record ID, depending
+// on the server version.
+var IDFields = []string{"id", "_id", "uuid"}
+
+// recordsPath is the path of the records endpoint, relative to the server URL.
+const recordsPath = "/records"
+
+// recordBody is the body of the record creation and update requests. The
+// encoding/json package marshals the struct fields in their declaration
+// order, so a given record always produces the same bytes, which keeps the
+// requests reproducible for signing proxies. Keep the field order stable.
+type recordBody struct {
+	Name   string `json:"name"`
+	Target string `json:"target"`
+}
+
 type Client struct {
-	url   string
-	token string
+	url        string
+	token      string
+	httpClient *http.Client
+	headers    http.Header
+
+	// idField is the response field holding the record ID.
+	idField string
+
+	// managedPrefix restricts the listed records to the ones whose name
+	// starts with it.
+	managedPrefix string
+
+	// maxRequestBytes is the maximum size of a request body, 0 means no limit.
+	maxRequestBytes int64
+
+	// forbidSelfReference rejects the records targeting the server itself.
+	forbidSelfReference bool
+
+	// capabilities caches the server capabilities, discovered once by
+	// capabilitiesOnce. capabilitiesMu only guards the cached value so the
+	// discovery request runs without it.
+	capabilities     Capabilities
+	capabilitiesOnce sync.Once
+	capabilitiesMu   sync.Mutex
+
+	// recordGetWarning logs once the fallback of GetRecord.
+	recordGetWarning sync.Once
 }
 
-func NewClient(url, token string) (*Client, error) {
-	return &Client{
-		url:   strings.TrimSuffix(url, "/"),
-		token: token,
-	}, nil
+// Option customizes the client built by NewClient.
+type Option func(*Client)
+
+// WithLocalAddress binds the outgoing connections to the given local IP address.
+func WithLocalAddress(ip net.IP) Option {
+	return func(c *Client) {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			LocalAddr: &net.TCPAddr{IP: ip},
+		}
+
+		// Keep the default settings, unless the default transport was
+		// replaced by another implementation
+		transport, ok := http.DefaultTransport.(*http.Transport)
+		if ok {
+			transport = transport.Clone()
+		} else {
+			transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+		}
+		transport.DialContext = dialer.DialContext
+
+		c.httpClient = &http.Client{Transport: transport}
+	}
 }
 
-func (c *Client) do(method, uri string, body any) (*http.Response, error) {
+// WithHeader sets an additional header on every request.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.headers.Set(key, value)
+	}
+}
+
+// WithIDField reads the record ID from the given response field, which
+// should be one of IDFields.
+func WithIDField(field string) Option {
+	return func(c *Client) {
+		c.idField = field
+	}
+}
+
+// WithManagedPrefix scopes the listed records to the ones whose name starts
+// with the given prefix.
+func WithManagedPrefix(prefix string) Option {
+	return func(c *Client) {
+		c.managedPrefix = strings.ToLower(prefix)
+	}
+}
+
+// WithMaxRequestBytes rejects the requests whose body is larger than the
+// given number of bytes, before sending them.
+func WithMaxRequestBytes(maxBytes int64) Option {
+	return func(c *Client) {
+		c.maxRequestBytes = maxBytes
+	}
+}
+
+// WithForbidSelfReference rejects the records whose target is an address of
+// the usg-dns API server.
+func WithForbidSelfReference() Option {
+	return func(c *Client) {
+		c.forbidSelfReference = true
+	}
+}
+
+func NewClient(url, token string, opts ...Option) (*Client, error) {
+	c := &Client{
+		url:        strings.TrimSuffix(url, "/"),
+		token:      token,
+		httpClient: http.DefaultClient,
+		headers:    make(http.Header),
+		idField:    "id",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// TrimRecordsPath removes the records endpoint path from the end of the
+// server URL, and reports whether it was present.
+func TrimRecordsPath(rawURL string) (string, bool) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL, false
+	}
+
+	trimmed := strings.TrimSuffix(parsedURL.Path, "/")
+	if !strings.HasSuffix(trimmed, recordsPath) {
+		return rawURL, false
+	}
+	parsedURL.Path = strings.TrimSuffix(trimmed, recordsPath)
+	parsedURL.RawPath = ""
+
+	return parsedURL.String(), true
+}
+
+func (c *Client) do(ctx context.Context, method, uri string, body any) (*http.Response, error) {
 	parsedURL, err := url.Parse(c.url + uri)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse the URL: %w", err)
 	}
 
-	var bodyReader io.Reader
+	var bodyBytes []byte
 	if body != nil {
-		bodyBytes, err := json.Marshal(body)
+		bodyBytes, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("unable to marshal the body: %w", err)
 		}
-		bodyReader = bytes.NewReader(bodyBytes)
+
+		if size := int64(len(bodyBytes)); c.maxRequestBytes > 0 && size > c.maxRequestBytes {
+			if record, ok := body.(recordBody); ok {
+				return nil, fmt.Errorf("the request body for the record %q is %d bytes, above the limit of %d bytes", record.Name, size, c.maxRequestBytes)
+			}
+			return nil, fmt.Errorf("the request body is %d bytes, above the limit of %d bytes", size, c.maxRequestBytes)
+		}
 	}
 
-	req, err := http.NewRequest(method, parsedURL.String(), bodyReader)
+	for attempt := 0; ; attempt++ {
+		var bodyReader io.Reader
+		if bodyBytes != nil {
+			bodyReader = bytes.NewReader(bodyBytes)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, parsedURL.String(), bodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("unable to build the request: %w", err)
+		}
+		for key, values := range c.headers {
+			req.Header[key] = values
+		}
+		req.Header.Set("Authorization", c.token)
+
+		res, err := c.httpClient.Do(req)
+		if err != nil || attempt >= maxRetries || !isRetryable(res, method) {
+			return res, err
+		}
+
+		// The server is overloaded or in maintenance, wait before retrying
+		wait := retryWait(res, attempt)
+		_ = res.Body.Close()
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) GetRecords(ctx context.Context) ([]usgdns.Record, error) {
+	records, err := c.listRecords(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("unable to build the request: %w", err)
+		return nil, err
 	}
-	req.Header.Set("Authorization", c.token)
 
-	return http.DefaultClient.Do(req)
+	if c.managedPrefix == "" {
+		return records, nil
+	}
+
+	managed := make([]usgdns.Record, 0, len(records))
+	for _, record := range records {
+		if c.isManaged(record.Name) {
+			managed = append(managed, record)
+		}
+	}
+
+	return managed, nil
 }
 
-func (c *Client) GetRecords() ([]usgdns.Record, error) {
-	res, err := c.do(http.MethodGet, "/records", nil)
-	if err == nil && res.StatusCode != http.StatusOK {
-		err = fmt.Errorf("unexpected status code: %d", res.StatusCode)
+// listRecords returns all the records, whatever the managed prefix.
+func (c *Client) listRecords(ctx context.Context) ([]usgdns.Record, error) {
+	res, err := c.do(ctx, http.MethodGet, recordsPath, nil)
+	if err == nil {
+		err = checkStatus(res, http.StatusOK)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("error while executing the request: %w", err)
 	}
 
-	var records []usgdns.Record
-	if err := unmarshal(res, &records); err != nil {
+	var rawRecords []json.RawMessage
+	if err := unmarshal(res, &rawRecords); err != nil {
 		return nil, fmt.Errorf("unable to get the result: %w", err)
 	}
 
+	records := make([]usgdns.Record, 0, len(rawRecords))
+	for _, rawRecord := range rawRecords {
+		record, err := c.decodeRecord(rawRecord)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the result: %w", err)
+		}
+		records = append(records, record)
+	}
+
 	return records, nil
 }
 
-func (c *Client) CreateRecord(name, target string) (usgdns.Record, error) {
-	res, err := c.do(http.MethodPost, "/records", usgdns.Record{
+// isManaged reports whether the record name belongs to the managed prefix.
+func (c *Client) isManaged(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), c.managedPrefix)
+}
+
+func (c *Client) CreateRecord(ctx context.Context, name, target string) (usgdns.Record, error) {
+	if c.forbidSelfReference {
+		if err := c.checkSelfReference(ctx, name, target); err != nil {
+			return usgdns.Record{}, err
+		}
+	}
+
+	res, err := c.do(ctx, http.MethodPost, recordsPath, recordBody{
 		Name:   name,
 		Target: target,
 	})
-	if err == nil && res.StatusCode != http.StatusCreated {
-		err = fmt.Errorf("unexpected status code: %d", res.StatusCode)
-
-		errMsg, err2 := getError(res)
-		if err2 == nil && errMsg != "" {
-			err = fmt.Errorf("%w: %s", err, errMsg)
-		}
+	if err == nil {
+		err = checkStatus(res, http.StatusCreated)
 	}
 	if err != nil {
 		return usgdns.Record{}, fmt.Errorf("error while executing the request: %w", err)
 	}
 
-	var record usgdns.Record
-	if err := unmarshal(res, &record); err != nil {
+	var rawRecord json.RawMessage
+	if err := unmarshal(res, &rawRecord); err != nil {
+		return usgdns.Record{}, fmt.Errorf("unable to get the result: %w", err)
+	}
+	record, err := c.decodeRecord(rawRecord)
+	if err != nil {
 		return usgdns.Record{}, fmt.Errorf("unable to get the result: %w", err)
 	}
 
 	return record, nil
 }
 
-func (c *Client) GetRecord(id string) (usgdns.Record, error) {
-	res, err := c.do(http.MethodGet, "/records/"+id, nil)
-	if err == nil && res.StatusCode != http.StatusOK {
-		err = fmt.Errorf("unexpected status code: %d", res.StatusCode)
+func (c *Client) GetRecord(ctx context.Context, id string) (usgdns.Record, error) {
+	if !c.Capabilities(ctx).RecordGet {
+		return c.findRecord(ctx, id)
+	}
 
-		errMsg, err2 := getError(res)
-		if err2 == nil && errMsg != "" {
-			err = fmt.Errorf("%w: %s", err, errMsg)
-		}
+	res, err := c.do(ctx, http.MethodGet, recordsPath+"/"+id, nil)
+	if err == nil && isMissingEndpoint(res) {
+		c.disableRecordGet()
+		return c.findRecord(ctx, id)
+	}
+	if err == nil {
+		err = checkStatus(res, http.StatusOK)
 	}
 	if err != nil {
 		return usgdns.Record{}, fmt.Errorf("error while executing the request: %w", err)
 	}
 
-	var record usgdns.Record
-	if err := unmarshal(res, &record); err != nil {
+	var rawRecord json.RawMessage
+	if err := unmarshal(res, &rawRecord); err != nil {
+		return usgdns.Record{}, fmt.Errorf("unable to get the result: %w", err)
+	}
+	record, err := c.decodeRecord(rawRecord)
+	if err != nil {
 		return usgdns.Record{}, fmt.Errorf("unable to get the result: %w", err)
 	}
 
 	return record, nil
 }
 
-func (c *Client) UpdateRecord(id, name, target string) (usgdns.Record, error) {
-	res, err := c.do(http.MethodPut, "/records/"+id, usgdns.Record{
-		Name:   name,
-		Target: target,
+// findRecord looks for a record in the records list, for the servers which
+// lack the single record endpoint.
+func (c *Client) findRecord(ctx context.Context, id string) (usgdns.Record, error) {
+	c.recordGetWarning.Do(func() {
+		tflog.Warn(ctx, "the usg-dns server does not implement GET "+recordsPath+"/{id}, falling back to listing the records")
 	})
-	if err == nil && res.StatusCode != http.StatusOK {
-		err = fmt.Errorf("unexpected status code: %d", res.StatusCode)
 
-		errMsg, err2 := getError(res)
-		if err2 == nil && errMsg != "" {
-			err = fmt.Errorf("%w: %s", err, errMsg)
+	records, err := c.listRecords(ctx)
+	if err != nil {
+		return usgdns.Record{}, err
+	}
+
+	for _, record := range records {
+		if record.ID == id {
+			return record, nil
 		}
 	}
+
+	return usgdns.Record{}, fmt.Errorf("error while executing the request: no record found with the ID %s", id)
+}
+
+func (c *Client) UpdateRecord(ctx context.Context, id, name, target string) (usgdns.Record, error) {
+	if c.forbidSelfReference {
+		if err := c.checkSelfReference(ctx, name, target); err != nil {
+			return usgdns.Record{}, err
+		}
+	}
+
+	res, err := c.do(ctx, http.MethodPut, recordsPath+"/"+id, recordBody{
+		Name:   name,
+		Target: target,
+	})
+	if err == nil {
+		err = checkStatus(res, http.StatusOK)
+	}
 	if err != nil {
 		return usgdns.Record{}, fmt.Errorf("error while executing the request: %w", err)
 	}
 
-	var record usgdns.Record
-	if err := unmarshal(res, &record); err != nil {
+	var rawRecord json.RawMessage
+	if err := unmarshal(res, &rawRecord); err != nil {
+		return usgdns.Record{}, fmt.Errorf("unable to get the result: %w", err)
+	}
+	record, err := c.decodeRecord(rawRecord)
+	if err != nil {
 		return usgdns.Record{}, fmt.Errorf("unable to get the result: %w", err)
 	}
 
 	return record, nil
 }
 
-func (c *Client) DeleteRecord(id string) error {
-	res, err := c.do(http.MethodDelete, "/records/"+id, nil)
-	if err == nil && res.StatusCode != http.StatusNoContent {
-		err = fmt.Errorf("unexpected status code: %d", res.StatusCode)
+func (c *Client) DeleteRecord(ctx context.Context, id string) error {
+	res, err := c.do(ctx, http.MethodDelete, recordsPath+"/"+id, nil)
+	if err == nil {
+		err = checkStatus(res, http.StatusNoContent)
 	}
 	if err != nil {
 		return fmt.Errorf("error while executing the request: %w", err)
@@ -152,23 +400,164 @@ func (c *Client) DeleteRecord(id string) error {
 	return nil
 }
 
+// RejectedRecord is a desired record which would be refused by the server.
+type RejectedRecord struct {
+	Record usgdns.Record
+	Reason string
+}
+
+// RecordsPreview is the outcome of a dry-run against the server.
+type RecordsPreview struct {
+	RecordChanges
+	Rejected []RejectedRecord
+}
+
+// PreviewRecords computes what would be created, updated, deleted or rejected
+// to reach the desired records, without mutating anything on the server.
+func (c *Client) PreviewRecords(ctx context.Context, desired []usgdns.Record) (RecordsPreview, error) {
+	actual, err := c.GetRecords(ctx)
+	if err != nil {
+		return RecordsPreview{}, err
+	}
+
+	var preview RecordsPreview
+	var accepted []usgdns.Record
+	seen := make(map[string]struct{}, len(desired))
+	for _, record := range desired {
+		name, err := NormalizeName(record.Name)
+		if err != nil {
+			preview.Rejected = append(preview.Rejected, RejectedRecord{Record: record, Reason: err.Error()})
+			continue
+		}
+		record.Name = name
+
+		if err := ValidateRecord(record.Name, record.Target); err != nil {
+			preview.Rejected = append(preview.Rejected, RejectedRecord{Record: record, Reason: err.Error()})
+			continue
+		}
+		if !c.isManaged(record.Name) {
+			preview.Rejected = append(preview.Rejected, RejectedRecord{Record: record, Reason: "name outside of the managed prefix " + c.managedPrefix})
+			continue
+		}
+		if _, ok := seen[record.Name]; ok {
+			preview.Rejected = append(preview.Rejected, RejectedRecord{Record: record, Reason: "duplicate name " + record.Name})
+			continue
+		}
+		seen[record.Name] = struct{}{}
+		accepted = append(accepted, record)
+	}
+
+	preview.RecordChanges = DiffRecords(accepted, actual)
+
+	return preview, nil
+}
+
 func unmarshal(res *http.Response, ret any) error {
 	bodyBytes, err := io.ReadAll(res.Body)
 	if err != nil {
 		return fmt.Errorf("unable to read the body: %w", err)
 	}
+	if isHTML(res, bodyBytes) {
+		return fmt.Errorf("%w (%s %s)", ErrHTMLResponse, res.Request.Method, res.Request.URL)
+	}
 	if err := json.Unmarshal(bodyBytes, &ret); err != nil {
 		return fmt.Errorf("unable to unmarshal the body: %w", err)
 	}
 	return nil
 }
 
+// isHTML reports whether the response is an HTML page, either from its
+// content type or from the beginning of its body.
+func isHTML(res *http.Response, bodyBytes []byte) bool {
+	if strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") {
+		return true
+	}
+	start := strings.ToLower(string(bytes.TrimSpace(bodyBytes[:min(len(bodyBytes), 512)])))
+	return strings.HasPrefix(start, "<!doctype html") || strings.HasPrefix(start, "<html")
+}
+
+// decodeRecord decodes a record, reading its ID from the configured field.
+func (c *Client) decodeRecord(data json.RawMessage) (usgdns.Record, error) {
+	var record usgdns.Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return usgdns.Record{}, fmt.Errorf("unable to unmarshal the record: %w", err)
+	}
+	if c.idField == "id" {
+		return record, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return usgdns.Record{}, fmt.Errorf("unable to unmarshal the record: %w", err)
+	}
+	rawID, ok := fields[c.idField]
+	if !ok {
+		return usgdns.Record{}, fmt.Errorf("the record has no %q field", c.idField)
+	}
+	if err := json.Unmarshal(rawID, &record.ID); err != nil {
+		return usgdns.Record{}, fmt.Errorf("unable to unmarshal the record %q field: %w", c.idField, err)
+	}
+
+	return record, nil
+}
+
+// isMissingEndpoint reports whether the response tells the endpoint does not
+// exist, as opposed to a missing record: the server answers 405, or 404
+// with the plain text body of the router for the unknown routes. Any JSON
+// 404 comes from the handler and is a missing record. The body is kept
+// readable for the caller.
+func isMissingEndpoint(res *http.Response) bool {
+	switch res.StatusCode {
+	case http.StatusMethodNotAllowed:
+		return true
+	case http.StatusNotFound:
+		bodyBytes, err := io.ReadAll(res.Body)
+		if err != nil {
+			return false
+		}
+		res.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+
+		return !json.Valid(bodyBytes)
+	default:
+		return false
+	}
+}
+
+// checkStatus returns an error, including the server message if any, when
+// the response status code is not the expected one.
+func checkStatus(res *http.Response, expected int) error {
+	if res.StatusCode == expected {
+		return nil
+	}
+
+	err := fmt.Errorf("unexpected status code: %d", res.StatusCode)
+
+	errMsg, err2 := getError(res)
+	if err2 == nil && errMsg != "" {
+		err = fmt.Errorf("%w: %s", err, errMsg)
+	} else if errors.Is(err2, ErrHTMLResponse) {
+		err = fmt.Errorf("%w: %w", err2, err)
+	}
+
+	if res.StatusCode == http.StatusUnauthorized && strings.Contains(strings.ToLower(errMsg), "not yet valid") {
+		err = fmt.Errorf("%w: %w", ErrTokenNotYetValid, err)
+	}
+
+	return err
+}
+
 func getError(res *http.Response) (string, error) {
+	// The records handlers use the message field while the authentication
+	// middleware uses the error one.
 	var ret struct {
 		Message string `json:"message"`
+		Error   string `json:"error"`
 	}
 	if err := unmarshal(res, &ret); err != nil {
 		return "", err
 	}
+	if ret.Message == "" {
+		return ret.Error, nil
+	}
 	return ret.Message, nil
 }
@@ -0,0 +1,381 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	usgdns "github.com/rclsilver-org/usg-dns-api/db"
+)
+
+func TestPreviewRecords(t *testing.T) {
+	server := newFakeServer(t,
+		newRecord("1", "app-kept.lan", "10.0.0.1"),
+		newRecord("2", "app-changed.lan", "10.0.0.2"),
+		newRecord("3", "app-removed.lan", "10.0.0.3"),
+		newRecord("4", "other.lan", "10.0.0.4"),
+	)
+	client, err := NewClient(server.URL, "token", WithManagedPrefix("app-"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := client.PreviewRecords(context.Background(), []usgdns.Record{
+		newRecord("", "app-kept.lan", "10.0.0.1"),
+		newRecord("", "app-changed.lan.", "10.0.0.20"),
+		newRecord("", "app-added.lan", "10.0.0.5"),
+		newRecord("", "app-added.lan", "10.0.0.6"),
+		newRecord("", "app-invalid.lan", "not-an-ip"),
+		newRecord("", "other-added.lan", "10.0.0.7"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := recordNames(preview.Create); !slices.Equal(got, []string{"app-added.lan"}) {
+		t.Errorf("unexpected records to create: %v", got)
+	}
+	if got := recordNames(preview.Update); !slices.Equal(got, []string{"app-changed.lan"}) {
+		t.Errorf("unexpected records to update: %v", got)
+	}
+	// other.lan is outside of the managed prefix and must not be deleted
+	if got := recordNames(preview.Delete); !slices.Equal(got, []string{"app-removed.lan"}) {
+		t.Errorf("unexpected records to delete: %v", got)
+	}
+
+	var rejected []string
+	for _, record := range preview.Rejected {
+		rejected = append(rejected, record.Record.Name+": "+record.Reason)
+	}
+	want := []string{
+		"app-added.lan: duplicate name app-added.lan",
+		`app-invalid.lan: invalid target "not-an-ip": must be an IP address`,
+		"other-added.lan: name outside of the managed prefix app-",
+	}
+	if !slices.Equal(rejected, want) {
+		t.Errorf("unexpected rejected records:\ngot:  %q\nwant: %q", rejected, want)
+	}
+
+	// The preview must not mutate anything
+	if count := server.requestCount(http.MethodPost, recordsPath); count != 0 {
+		t.Errorf("the preview sent %d creation requests", count)
+	}
+}
+
+func TestGetRecordFallback(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &logs)
+
+	server := newFakeServer(t,
+		newRecord("1", "first.lan", "10.0.0.1"),
+		newRecord("2", "second.lan", "10.0.0.2"),
+	)
+	server.noRecordGet = true
+
+	client, err := NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"1", "2"} {
+		record, err := client.GetRecord(ctx, id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if record.ID != id {
+			t.Errorf("expected the record %s, got %s", id, record.ID)
+		}
+	}
+
+	// The endpoint is only probed until found missing
+	if count := server.requestCount(http.MethodGet, recordsPath+"/1"); count != 1 {
+		t.Errorf("expected 1 request to the single record endpoint, got %d", count)
+	}
+	if count := server.requestCount(http.MethodGet, recordsPath+"/2"); count != 0 {
+		t.Errorf("expected no request to the single record endpoint once disabled, got %d", count)
+	}
+	if count := strings.Count(logs.String(), "falling back to listing the records"); count != 1 {
+		t.Errorf("expected the fallback warning to be logged once, got %d times", count)
+	}
+}
+
+func TestGetRecordJSONNotFound(t *testing.T) {
+	var recordRequests, listRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case recordsPath:
+			listRequests.Add(1)
+			writeJSON(w, http.StatusOK, []any{})
+		case recordsPath + "/1":
+			recordRequests.Add(1)
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if _, err := client.GetRecord(context.Background(), "1"); err == nil {
+			t.Fatal("expected an error for the missing record")
+		}
+	}
+
+	// A JSON 404 is a missing record and keeps the endpoint enabled
+	if count := recordRequests.Load(); count != 2 {
+		t.Errorf("expected 2 requests to the single record endpoint, got %d", count)
+	}
+	if count := listRequests.Load(); count != 0 {
+		t.Errorf("expected no fallback to the records list, got %d requests", count)
+	}
+	if !client.Capabilities(context.Background()).RecordGet {
+		t.Error("the single record endpoint should stay enabled")
+	}
+}
+
+func TestWithLocalAddress(t *testing.T) {
+	remoteAddrs := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteAddrs <- r.RemoteAddr
+		writeJSON(w, http.StatusOK, []any{})
+	}))
+	t.Cleanup(server.Close)
+
+	// Another loopback address than the one of the server, when available
+	localAddress := net.ParseIP("127.0.0.2")
+	dialer := net.Dialer{LocalAddr: &net.TCPAddr{IP: localAddress}}
+	conn, err := dialer.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Skipf("unable to bind to %s: %v", localAddress, err)
+	}
+	_ = conn.Close()
+
+	client, err := NewClient(server.URL, "token", WithLocalAddress(localAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetRecords(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The other settings are the default ones
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	defaultTransport, defaultOK := http.DefaultTransport.(*http.Transport)
+	if !ok || !defaultOK {
+		t.Fatalf("unexpected transport %T", client.httpClient.Transport)
+	}
+	if transport.Proxy == nil || transport.MaxIdleConns != defaultTransport.MaxIdleConns ||
+		transport.IdleConnTimeout != defaultTransport.IdleConnTimeout ||
+		transport.ForceAttemptHTTP2 != defaultTransport.ForceAttemptHTTP2 {
+		t.Error("the transport should keep the default settings")
+	}
+
+	host, _, err := net.SplitHostPort(<-remoteAddrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != localAddress.String() {
+		t.Errorf("expected the request to come from %s, got %s", localAddress, host)
+	}
+}
+
+func TestDecodeRecord(t *testing.T) {
+	tests := []struct {
+		idField string
+		data    string
+		wantID  string
+		wantErr string
+	}{
+		{idField: "id", data: `{"id":"1","name":"a.lan","target":"10.0.0.1"}`, wantID: "1"},
+		{idField: "_id", data: `{"_id":"2","name":"a.lan","target":"10.0.0.1"}`, wantID: "2"},
+		{idField: "uuid", data: `{"uuid":"3","id":"other","name":"a.lan","target":"10.0.0.1"}`, wantID: "3"},
+		{idField: "_id", data: `{"id":"1","name":"a.lan","target":"10.0.0.1"}`, wantErr: `the record has no "_id" field`},
+		{idField: "uuid", data: `{"uuid":3,"name":"a.lan","target":"10.0.0.1"}`, wantErr: `unable to unmarshal the record "uuid" field`},
+	}
+
+	for _, test := range tests {
+		client, err := NewClient("http://127.0.0.1", "token", WithIDField(test.idField))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		record, err := client.decodeRecord(json.RawMessage(test.data))
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s %s: expected the error %q, got %v", test.idField, test.data, test.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s: unexpected error: %v", test.idField, test.data, err)
+			continue
+		}
+		if record.ID != test.wantID || record.Name != "a.lan" || record.Target != "10.0.0.1" {
+			t.Errorf("%s %s: unexpected record %+v", test.idField, test.data, record)
+		}
+	}
+}
+
+func TestTrimRecordsPath(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		trimmed bool
+	}{
+		{url: "http://127.0.0.1:8080/records", want: "http://127.0.0.1:8080", trimmed: true},
+		{url: "http://127.0.0.1:8080/records/", want: "http://127.0.0.1:8080", trimmed: true},
+		{url: "http://127.0.0.1:8080/api/records", want: "http://127.0.0.1:8080/api", trimmed: true},
+		{url: "http://127.0.0.1:8080/api", want: "http://127.0.0.1:8080/api"},
+		{url: "http://records", want: "http://records"},
+	}
+
+	for _, test := range tests {
+		got, trimmed := TrimRecordsPath(test.url)
+		if got != test.want || trimmed != test.trimmed {
+			t.Errorf("%s: expected %s (%t), got %s (%t)", test.url, test.want, test.trimmed, got, trimmed)
+		}
+	}
+}
+
+func TestTokenNotYetValid(t *testing.T) {
+	tests := map[string]bool{
+		`{"error":"token not yet valid"}`:    true,
+		`{"error":"Token is not yet valid"}`: true,
+		`{"error":"invalid token"}`:          false,
+	}
+
+	for body, notYetValid := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(body))
+		}))
+
+		client, err := NewClient(server.URL, "token")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.GetRecords(context.Background())
+		server.Close()
+
+		if err == nil {
+			t.Errorf("%s: expected an error", body)
+		} else if errors.Is(err, ErrTokenNotYetValid) != notYetValid {
+			t.Errorf("%s: expected ErrTokenNotYetValid %t, got %v", body, notYetValid, err)
+		}
+	}
+}
+
+func TestMaxRequestBytes(t *testing.T) {
+	body, err := json.Marshal(recordBody{Name: "sized.lan", Target: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := int64(len(body))
+
+	// A body of exactly the limit is sent
+	server := newFakeServer(t)
+	client, err := NewClient(server.URL, "token", WithMaxRequestBytes(size))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateRecord(context.Background(), "sized.lan", "10.0.0.1"); err != nil {
+		t.Fatalf("unexpected error under the limit: %v", err)
+	}
+
+	// A larger body is refused before being sent
+	server = newFakeServer(t)
+	client, err = NewClient(server.URL, "token", WithMaxRequestBytes(size-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CreateRecord(context.Background(), "sized.lan", "10.0.0.1")
+	if err == nil || !strings.Contains(err.Error(), `the record "sized.lan"`) {
+		t.Errorf("expected an error naming the record, got %v", err)
+	}
+	if count := server.requestCount(http.MethodPost, recordsPath); count != 0 {
+		t.Errorf("the request above the limit should not be sent, got %d requests", count)
+	}
+}
+
+func TestHTMLResponse(t *testing.T) {
+	tests := map[string]struct {
+		statusCode  int
+		contentType string
+	}{
+		"200":              {statusCode: http.StatusOK, contentType: "text/html; charset=utf-8"},
+		"200 without type": {statusCode: http.StatusOK},
+		"404":              {statusCode: http.StatusNotFound, contentType: "text/html"},
+	}
+
+	for name, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if test.contentType != "" {
+				w.Header().Set("Content-Type", test.contentType)
+			}
+			w.WriteHeader(test.statusCode)
+			_, _ = w.Write([]byte("<!DOCTYPE html>\n<html><body>UniFi</body></html>"))
+		}))
+
+		client, err := NewClient(server.URL, "token")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.GetRecords(context.Background())
+		server.Close()
+
+		if !errors.Is(err, ErrHTMLResponse) {
+			t.Errorf("%s: expected ErrHTMLResponse, got %v", name, err)
+		}
+	}
+}
+
+func TestRecordBodyBytes(t *testing.T) {
+	const want = `{"name":"stable.lan","target":"10.0.0.1"}`
+
+	got, err := json.Marshal(recordBody{Name: "stable.lan", Target: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("unexpected body:\ngot:  %s\nwant: %s", got, want)
+	}
+
+	// The same bytes are sent on the wire
+	bodies := make(chan []byte, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		writeJSON(w, http.StatusOK, map[string]string{"id": "1", "name": "stable.lan", "target": "10.0.0.1"})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.UpdateRecord(context.Background(), "1", "stable.lan", "10.0.0.1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body := <-bodies; string(body) != want {
+		t.Errorf("unexpected request body:\ngot:  %s\nwant: %s", body, want)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package usgdns
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+)
+
+// validateNameRegexp mirrors the rule enforced by the usg-dns-api server.
+var validateNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9\-\.]{0,61}[a-zA-Z0-9])?$`)
+
+// ValidateName checks the record name the same way the server does.
+func ValidateName(name string) error {
+	if !validateNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid name %q", name)
+	}
+	return nil
+}
+
+// ValidateTarget checks the record target the same way the server does.
+func ValidateTarget(target string) error {
+	if _, err := netip.ParseAddr(target); err != nil {
+		return fmt.Errorf("invalid target %q: must be an IP address", target)
+	}
+	return nil
+}
+
+// ValidateRecord runs every record check and returns all the failures at once.
+func ValidateRecord(name, target string) error {
+	return errors.Join(ValidateName(name), ValidateTarget(target))
+}